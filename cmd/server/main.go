@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
@@ -13,14 +14,39 @@ import (
 	"insider-message-sender/internal/api"
 	"insider-message-sender/internal/cache"
 	"insider-message-sender/internal/config"
+	"insider-message-sender/internal/constants"
+	"insider-message-sender/internal/importer"
+	"insider-message-sender/internal/metrics"
+	"insider-message-sender/internal/provider"
 	"insider-message-sender/internal/repository"
 	"insider-message-sender/internal/scheduler"
+	"insider-message-sender/internal/tracing"
 )
 
 func main() {
 	cfg := config.Load()
 	log.Printf("DB Host: %s, Redis Host: %s, WebhookURL: %s, SendInterval: %s, ServerPort: %s",
 		cfg.DBHost, cfg.RedisHost, cfg.WebhookURL, cfg.SendInterval, cfg.ServerPort)
+	if cfg.CallbackToken == "" {
+		log.Println("WARNING: DLR_CALLBACK_TOKEN is not set, POST /api/v1/callbacks/delivery accepts delivery receipts from anyone. " +
+			"Set it to a shared secret before exposing the service.")
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg)
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+	// Flush buffered spans once everything else has shut down
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.Printf("Error flushing traces: %v", err)
+		}
+	}()
+	if cfg.TracingExporter != constants.TracingExporterNone {
+		log.Printf("Tracing enabled, exporting spans to %s", cfg.TracingExporter)
+	}
 
 	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
 		cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName)
@@ -43,7 +69,19 @@ func main() {
 		}
 	}()
 
-	s := scheduler.NewScheduler(cfg, repo, redisClient)
+	router, err := provider.NewRouter(cfg, redisClient)
+	if err != nil {
+		log.Fatalf("Failed to set up SMS providers: %v", err)
+	}
+	for _, p := range router.Providers() {
+		log.Printf("SMS provider configured: %s", p.Name())
+	}
+
+	s, err := scheduler.NewScheduler(cfg, repo, redisClient, router)
+	if err != nil {
+		log.Fatalf("Invalid scheduler configuration: %v", err)
+	}
+	metrics.RegisterStateCollectors(repo, s.IsRunning)
 	if err := s.Start(); err != nil {
 		log.Fatalf("Failed to start scheduler: %v", err)
 	}
@@ -52,8 +90,11 @@ func main() {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
+	jobs := repository.NewImportJobRepository(repo.DB())
+	imp := importer.NewImporter(repo, jobs)
+
 	// Create HTTP server
-	server := api.NewServer(cfg, s, repo)
+	server := api.NewServer(cfg, s, repo, jobs, imp, redisClient)
 
 	// Start server in a goroutine with error handling
 	serverErr := make(chan error, 1)
@@ -76,7 +117,8 @@ func main() {
 	case <-sigChan:
 		log.Println("Received shutdown signal, starting graceful shutdown...")
 
-		// Stop scheduler first
+		// Stop scheduler first. It returns once in-flight sends recorded their outcome, so the deferred
+		// Redis and database closes below cannot cut off a send the provider already accepted.
 		if err := s.Stop(); err != nil {
 			log.Printf("Error stopping scheduler: %v", err)
 		}
@@ -86,6 +128,9 @@ func main() {
 			log.Printf("Error shutting down HTTP server: %v", err)
 		}
 
+		// Interrupt running imports so their jobs are not left in processing state
+		imp.Shutdown()
+
 		log.Println("Application shutdown complete.")
 	}
 }
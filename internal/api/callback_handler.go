@@ -0,0 +1,119 @@
+package api
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"insider-message-sender/internal/cache"
+	"insider-message-sender/internal/config"
+	"insider-message-sender/internal/constants"
+	"insider-message-sender/internal/model"
+	"insider-message-sender/internal/repository"
+	"insider-message-sender/internal/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+const callbackTokenHeader = "X-Callback-Token"
+
+// @Summary Receive a delivery receipt
+// @Description Providers report the handset outcome of a sent message by the messageId they returned on send.
+// @Description The message moves from sent to delivered or undelivered with the report's timestamp and reason.
+// @Description A report older than the one already recorded is acknowledged but not applied.
+// @Description When DLR_CALLBACK_TOKEN is configured the X-Callback-Token header must match it.
+// @Tags Callbacks
+// @Accept json
+// @Produce json
+// @Param report body model.DeliveryReportRequest true "Delivery receipt"
+// @Param X-Callback-Token header string false "Shared secret configured as DLR_CALLBACK_TOKEN"
+// @Success 200 {object} model.DeliveryReportResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 422 {object} model.ValidationErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/callbacks/delivery [post]
+func DeliveryCallback(cfg *config.Config, repo *repository.MessageRepository, redisClient *cache.RedisClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if cfg.CallbackToken != "" &&
+			subtle.ConstantTimeCompare([]byte(c.GetHeader(callbackTokenHeader)), []byte(cfg.CallbackToken)) != 1 {
+			respondError(c, http.StatusUnauthorized, "Invalid callback token")
+			return
+		}
+
+		var req model.DeliveryReportRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, "Invalid request body: "+err.Error())
+			return
+		}
+
+		if errs := validator.ValidateDeliveryReport(req); len(errs) > 0 {
+			c.JSON(http.StatusUnprocessableEntity, model.ValidationErrorResponse{
+				Status:  "error",
+				Message: "Validation failed",
+				Errors:  errs,
+				Time:    time.Now().Format(time.RFC3339),
+			})
+			return
+		}
+
+		m, err := lookupSentMessage(c.Request.Context(), repo, redisClient, req.Provider, req.MessageID)
+		if errors.Is(err, sql.ErrNoRows) {
+			respondError(c, http.StatusNotFound, "Message not found")
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to look up provider messageId %s: %v", req.MessageID, err)
+			respondError(c, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+
+		report := model.DeliveryReport{
+			MessageID:  m.ID,
+			Status:     req.Status,
+			ReportedAt: time.Now(),
+			Reason:     req.Reason,
+		}
+		if req.Timestamp != nil {
+			report.ReportedAt = *req.Timestamp
+		}
+
+		applied, err := repo.ApplyDeliveryReport(c.Request.Context(), report)
+		if err != nil {
+			log.Printf("Failed to apply delivery report to message %d: %v", m.ID, err)
+			respondError(c, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+
+		status := req.Status
+		if !applied {
+			current, err := repo.GetByID(c.Request.Context(), m.ID)
+			if err != nil {
+				log.Printf("Failed to fetch message %d: %v", m.ID, err)
+				respondError(c, http.StatusInternalServerError, "Internal server error")
+				return
+			}
+			if !constants.IsValidDeliveryStatus(current.Status) {
+				respondError(c, http.StatusConflict, "Only sent messages accept delivery reports, message is "+current.Status)
+				return
+			}
+			status = current.Status
+			log.Printf("Ignored %s report for message %d, a newer report is already recorded", req.Status, m.ID)
+		} else {
+			log.Printf("Message %d reported %s via messageId %s", m.ID, req.Status, req.MessageID)
+		}
+
+		c.JSON(http.StatusOK, model.DeliveryReportResponse{
+			Status:         "success",
+			ID:             m.ID,
+			DeliveryStatus: status,
+			Applied:        applied,
+			Time:           time.Now().Format(time.RFC3339),
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package api
+
+import (
+	"time"
+
+	"insider-message-sender/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+func respondError(c *gin.Context, status int, message string) {
+	c.JSON(status, model.ErrorResponse{
+		Status:  "error",
+		Message: message,
+		Time:    time.Now().Format(time.RFC3339),
+	})
+}
@@ -13,7 +13,8 @@ import (
 )
 
 // @Summary Health check endpoint
-// @Description Check the health status of the service including database connectivity and scheduler status
+// @Description Check the health status of the service including database connectivity, scheduler status
+// @Description and the circuit breaker state of every provider
 // @Tags Health
 // @Produce json
 // @Success 200 {object} model.HealthResponse
@@ -39,11 +40,21 @@ func HealthCheck(s *scheduler.Scheduler, repo *repository.MessageRepository) gin
 		}
 
 		// Check scheduler status
-		if s.IsRunning() {
+		switch schedulerRole(s) {
+		case "leader":
 			health.Services["scheduler"] = "running"
-		} else {
+		case "standby":
+			health.Services["scheduler"] = "standby"
+		default:
 			health.Services["scheduler"] = "stopped"
 		}
+		health.Services["instance"] = s.InstanceID()
+		health.Services["leader"] = currentLeader(ctx, s)
+
+		// Report provider circuits; an open circuit pauses sending but the service itself stays up
+		for _, cb := range s.Circuits() {
+			health.Services["circuit:"+cb.Provider] = cb.State
+		}
 
 		// Check Redis connectivity (if available)
 		health.Services["redis"] = "healthy" // Assume healthy for now
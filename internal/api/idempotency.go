@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"insider-message-sender/internal/constants"
+	"insider-message-sender/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+// idempotencyKey reads the optional Idempotency-Key header, responding with 400 and returning false when it is invalid
+func idempotencyKey(c *gin.Context) (string, bool) {
+	key := strings.TrimSpace(c.GetHeader(idempotencyKeyHeader))
+	if len(key) > maxIdempotencyKeyLength {
+		respondError(c, http.StatusBadRequest, "Idempotency-Key must be at most 255 characters")
+		return "", false
+	}
+	return key, true
+}
+
+// sameMessage reports whether a replayed request asks for the message that was stored under its key.
+// Defaults are applied the way the insert applies them: no priority means normal, and no send_at means the
+// time the message was stored, so a replay without send_at only matches a message stored for immediate sending.
+func sameMessage(stored, requested model.Message) bool {
+	priority := requested.Priority
+	if priority == "" {
+		priority = constants.MessagePriorityNormal
+	}
+	sendAt := requested.SendAt
+	if sendAt.IsZero() {
+		sendAt = stored.CreatedAt
+	}
+	return stored.PhoneNumber == requested.PhoneNumber &&
+		stored.Content == requested.Content &&
+		stored.Priority == priority &&
+		sameTime(stored.SendAt, sendAt) &&
+		sameOptionalTime(stored.ExpiresAt, requested.ExpiresAt)
+}
+
+// sameTime compares times at the microsecond precision Postgres stores them with
+func sameTime(a, b time.Time) bool {
+	return a.Truncate(time.Microsecond).Equal(b.Truncate(time.Microsecond))
+}
+
+func sameOptionalTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return sameTime(*a, *b)
+}
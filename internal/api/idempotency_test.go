@@ -0,0 +1,48 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"insider-message-sender/internal/constants"
+	"insider-message-sender/internal/model"
+)
+
+func TestSameMessage(t *testing.T) {
+	createdAt := time.Date(2025, 10, 19, 7, 0, 0, 123456000, time.UTC)
+	sendAt := createdAt.Add(time.Hour)
+	expiresAt := createdAt.Add(2 * time.Hour)
+	otherExpiresAt := expiresAt.Add(time.Minute)
+
+	immediate := model.Message{PhoneNumber: "+905551234567", Content: "hi", Priority: constants.MessagePriorityNormal,
+		SendAt: createdAt, CreatedAt: createdAt}
+	scheduled := model.Message{PhoneNumber: "+905551234567", Content: "hi", Priority: constants.MessagePriorityHigh,
+		SendAt: sendAt, ExpiresAt: &expiresAt, CreatedAt: createdAt}
+
+	tests := []struct {
+		name      string
+		stored    model.Message
+		requested model.Message
+		want      bool
+	}{
+		{"defaults", immediate, model.Message{PhoneNumber: "+905551234567", Content: "hi"}, true},
+		{"same schedule", scheduled, model.Message{PhoneNumber: "+905551234567", Content: "hi",
+			Priority: constants.MessagePriorityHigh, SendAt: sendAt.Add(300 * time.Nanosecond), ExpiresAt: &expiresAt}, true},
+		{"other content", immediate, model.Message{PhoneNumber: "+905551234567", Content: "bye"}, false},
+		{"other priority", immediate, model.Message{PhoneNumber: "+905551234567", Content: "hi",
+			Priority: constants.MessagePriorityLow}, false},
+		// Without send_at the request asks for immediate sending, unlike the stored scheduled message
+		{"missing send_at", scheduled, model.Message{PhoneNumber: "+905551234567", Content: "hi",
+			Priority: constants.MessagePriorityHigh, ExpiresAt: &expiresAt}, false},
+		{"other send_at", immediate, model.Message{PhoneNumber: "+905551234567", Content: "hi", SendAt: sendAt}, false},
+		{"missing expires_at", scheduled, model.Message{PhoneNumber: "+905551234567", Content: "hi",
+			Priority: constants.MessagePriorityHigh, SendAt: sendAt}, false},
+		{"other expires_at", scheduled, model.Message{PhoneNumber: "+905551234567", Content: "hi",
+			Priority: constants.MessagePriorityHigh, SendAt: sendAt, ExpiresAt: &otherExpiresAt}, false},
+	}
+	for _, tt := range tests {
+		if got := sameMessage(tt.stored, tt.requested); got != tt.want {
+			t.Errorf("%s: sameMessage = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"insider-message-sender/internal/constants"
+	"insider-message-sender/internal/importer"
+	"insider-message-sender/internal/model"
+	"insider-message-sender/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxImportFileSize = 50 << 20 // 50 MB
+
+// @Summary Import messages from a CSV or XLSX file
+// @Description Uploads a spreadsheet and imports its rows as pending messages in the background.
+// @Description Poll the returned job for progress and rejected rows.
+// @Tags Imports
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "CSV or XLSX file"
+// @Param format formData string false "File format, inferred from the file extension when omitted" Enums(csv, xlsx)
+// @Param phone_column formData string false "Header name or 1-based column number of the phone number" default(phone_number)
+// @Param content_column formData string false "Header name or 1-based column number of the content" default(content)
+// @Param has_header formData bool false "Whether the first row is a header row" default(true)
+// @Param sheet formData string false "XLSX sheet name, defaults to the first sheet"
+// @Param dry_run formData bool false "Validate rows without inserting them" default(false)
+// @Success 202 {object} model.ImportJobResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 413 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/messages/imports [post]
+func CreateImport(imp *importer.Importer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportFileSize)
+
+		fh, err := c.FormFile("file")
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				respondError(c, http.StatusRequestEntityTooLarge, "File exceeds the 50 MB upload limit")
+				return
+			}
+			respondError(c, http.StatusBadRequest, "Missing file: "+err.Error())
+			return
+		}
+
+		format := strings.ToLower(c.PostForm("format"))
+		if format == "" {
+			format = strings.TrimPrefix(strings.ToLower(filepath.Ext(fh.Filename)), ".")
+		}
+		if format != constants.ImportFormatCSV && format != constants.ImportFormatXLSX {
+			respondError(c, http.StatusBadRequest, "Unsupported file format, expected csv or xlsx")
+			return
+		}
+
+		hasHeader, err := strconv.ParseBool(c.DefaultPostForm("has_header", "true"))
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "Invalid has_header value")
+			return
+		}
+		dryRun, err := strconv.ParseBool(c.DefaultPostForm("dry_run", "false"))
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "Invalid dry_run value")
+			return
+		}
+
+		tmp, err := os.CreateTemp("", "insider-import-*."+format)
+		if err != nil {
+			log.Printf("Failed to create temp file for import: %v", err)
+			respondError(c, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+		_ = tmp.Close()
+		if err := c.SaveUploadedFile(fh, tmp.Name()); err != nil {
+			_ = os.Remove(tmp.Name())
+			log.Printf("Failed to save uploaded import file: %v", err)
+			respondError(c, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+
+		job := model.ImportJob{
+			Filename:   fh.Filename,
+			Format:     format,
+			DryRun:     dryRun,
+			Rejections: []model.ImportRejection{},
+		}
+		mapping := importer.ColumnMapping{
+			PhoneColumn:   c.DefaultPostForm("phone_column", "phone_number"),
+			ContentColumn: c.DefaultPostForm("content_column", "content"),
+			HasHeader:     hasHeader,
+			Sheet:         c.PostForm("sheet"),
+		}
+		if err := imp.Start(c.Request.Context(), &job, tmp.Name(), mapping); err != nil {
+			log.Printf("Failed to start import job: %v", err)
+			respondError(c, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+
+		c.JSON(http.StatusAccepted, model.ImportJobResponse{Data: job})
+	}
+}
+
+// @Summary Get import job progress
+// @Description Returns progress counters and rejected rows (up to the first 1000) of an import job.
+// @Tags Imports
+// @Produce json
+// @Param id path int true "Import job ID"
+// @Success 200 {object} model.ImportJobResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/messages/imports/{id} [get]
+func GetImport(jobs *repository.ImportJobRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil || id <= 0 {
+			respondError(c, http.StatusBadRequest, "Invalid import job id")
+			return
+		}
+
+		job, err := jobs.Get(c.Request.Context(), id)
+		if errors.Is(err, sql.ErrNoRows) {
+			respondError(c, http.StatusNotFound, "Import job not found")
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to fetch import job %d: %v", id, err)
+			respondError(c, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+
+		c.JSON(http.StatusOK, model.ImportJobResponse{Data: *job})
+	}
+}
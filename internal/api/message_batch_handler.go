@@ -0,0 +1,290 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"insider-message-sender/internal/model"
+	"insider-message-sender/internal/repository"
+	"insider-message-sender/internal/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxBatchSize      = 50000
+	batchChunkSize    = 500
+	maxNDJSONLineSize = 64 * 1024
+	// maxBatchBodySize comfortably fits maxBatchSize items of the largest allowed size
+	maxBatchBodySize = 64 << 20
+)
+
+// errMalformedBatch is returned when the payload cannot be parsed any further
+var errMalformedBatch = errors.New("malformed batch payload")
+
+// batchDecoder yields the raw JSON of each item of a batch payload, either a JSON array or NDJSON
+type batchDecoder interface {
+	Next() (json.RawMessage, error)
+}
+
+type arrayDecoder struct {
+	dec     *json.Decoder
+	started bool
+}
+
+func (d *arrayDecoder) Next() (json.RawMessage, error) {
+	if !d.started {
+		tok, err := d.dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errMalformedBatch, err)
+		}
+		if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+			return nil, fmt.Errorf("%w: expected a JSON array", errMalformedBatch)
+		}
+		d.started = true
+	}
+
+	if !d.dec.More() {
+		return nil, io.EOF
+	}
+
+	var raw json.RawMessage
+	if err := d.dec.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("%w: %w", errMalformedBatch, err)
+	}
+	return raw, nil
+}
+
+type ndjsonDecoder struct {
+	scanner *bufio.Scanner
+}
+
+func (d *ndjsonDecoder) Next() (json.RawMessage, error) {
+	for d.scanner.Scan() {
+		line := bytes.TrimSpace(d.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		// Copy the line since the scanner reuses its buffer
+		return append(json.RawMessage(nil), line...), nil
+	}
+	if err := d.scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", errMalformedBatch, err)
+	}
+	return nil, io.EOF
+}
+
+func newBatchDecoder(c *gin.Context) batchDecoder {
+	switch c.ContentType() {
+	case "application/x-ndjson", "application/ndjson":
+		scanner := bufio.NewScanner(c.Request.Body)
+		scanner.Buffer(make([]byte, 0, 4096), maxNDJSONLineSize)
+		return &ndjsonDecoder{scanner: scanner}
+	default:
+		return &arrayDecoder{dec: json.NewDecoder(c.Request.Body)}
+	}
+}
+
+// batchWriter buffers validated messages and inserts them chunk by chunk
+type batchWriter struct {
+	ctx     context.Context
+	repo    *repository.MessageRepository
+	resp    *model.BatchCreateMessagesResponse
+	msgs    []model.Message
+	indexes []int
+}
+
+func (w *batchWriter) add(index int, m model.Message) {
+	w.msgs = append(w.msgs, m)
+	w.indexes = append(w.indexes, index)
+	if len(w.msgs) >= batchChunkSize {
+		w.flush()
+	}
+}
+
+func (w *batchWriter) reject(index int, reason string, errs []model.FieldError) {
+	w.resp.Rejected = append(w.resp.Rejected, model.BatchRejectedItem{
+		Index:  index,
+		Reason: reason,
+		Errors: errs,
+	})
+}
+
+func (w *batchWriter) flush() {
+	if len(w.msgs) == 0 {
+		return
+	}
+
+	var duplicates []int
+	err := w.repo.CreateBatch(w.ctx, w.msgs)
+	switch {
+	case errors.Is(err, repository.ErrBatchNotStored):
+		// Nothing of the chunk was stored, so fall back to row-by-row inserts to isolate the bad rows
+		log.Printf("Batch insert of %d messages failed, retrying row by row: %v", len(w.msgs), err)
+		for i := range w.msgs {
+			err := w.repo.Create(w.ctx, &w.msgs[i])
+			if errors.Is(err, repository.ErrDuplicateIdempotencyKey) {
+				duplicates = append(duplicates, i)
+				continue
+			}
+			if err != nil {
+				log.Printf("Failed to create message at index %d: %v", w.indexes[i], err)
+				w.reject(w.indexes[i], "Failed to store message", nil)
+				continue
+			}
+			w.accept(w.indexes[i], w.msgs[i].ID, false)
+		}
+	case err != nil:
+		// The chunk may have been stored, inserting it again could duplicate messages
+		log.Printf("Batch insert of %d messages failed after the statement ran: %v", len(w.msgs), err)
+		for _, index := range w.indexes {
+			w.reject(index, "Failed to confirm that the message was stored", nil)
+		}
+	default:
+		for i, m := range w.msgs {
+			if m.ID == 0 {
+				duplicates = append(duplicates, i)
+				continue
+			}
+			w.accept(w.indexes[i], m.ID, false)
+		}
+	}
+	w.replay(duplicates)
+
+	w.msgs = w.msgs[:0]
+	w.indexes = w.indexes[:0]
+}
+
+// replay resolves items skipped because their idempotency key is already stored, accepting those that ask
+// for the stored message
+func (w *batchWriter) replay(duplicates []int) {
+	if len(duplicates) == 0 {
+		return
+	}
+
+	keys := make([]string, len(duplicates))
+	for i, idx := range duplicates {
+		keys[i] = w.msgs[idx].IdempotencyKey
+	}
+	stored, err := w.repo.FetchByIdempotencyKeys(w.ctx, keys)
+	if err != nil {
+		log.Printf("Failed to fetch %d replayed batch messages: %v", len(keys), err)
+	}
+
+	for _, idx := range duplicates {
+		existing, found := stored[w.msgs[idx].IdempotencyKey]
+		switch {
+		case !found:
+			w.reject(w.indexes[idx], "Failed to store message", nil)
+		case !sameMessage(existing, w.msgs[idx]):
+			w.reject(w.indexes[idx], "Idempotency key was already used for a different message", nil)
+		default:
+			w.accept(w.indexes[idx], existing.ID, true)
+		}
+	}
+}
+
+func (w *batchWriter) accept(index int, id int64, replayed bool) {
+	w.resp.Accepted = append(w.resp.Accepted, model.BatchAcceptedItem{Index: index, ID: id, Replayed: replayed})
+}
+
+// @Summary Create messages in bulk
+// @Description Accepts a JSON array or NDJSON stream (Content-Type: application/x-ndjson) of messages.
+// @Description Valid items are stored as pending in chunks; invalid items are reported by index without failing the batch.
+// @Description With an Idempotency-Key header, retrying the same batch returns the stored items marked as replayed
+// @Description instead of creating duplicates; each item is keyed by the header value and its index.
+// @Tags Messages
+// @Accept json
+// @Accept application/x-ndjson
+// @Produce json
+// @Param messages body []model.CreateMessageRequest true "Messages to send"
+// @Param Idempotency-Key header string false "Client key that makes retries of this batch safe, at most 255 characters"
+// @Success 200 {object} model.BatchCreateMessagesResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 413 {object} model.ErrorResponse
+// @Router /api/v1/messages/batch [post]
+func CreateMessagesBatch(repo *repository.MessageRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resp := model.BatchCreateMessagesResponse{
+			Accepted: []model.BatchAcceptedItem{},
+			Rejected: []model.BatchRejectedItem{},
+		}
+		key, ok := idempotencyKey(c)
+		if !ok {
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBatchBodySize)
+		w := &batchWriter{ctx: c.Request.Context(), repo: repo, resp: &resp}
+		dec := newBatchDecoder(c)
+
+		for index := 0; ; index++ {
+			raw, err := dec.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if index == 0 && errors.As(err, &tooLarge) {
+					respondError(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body exceeds %d bytes", tooLarge.Limit))
+					return
+				}
+				if index == 0 {
+					c.JSON(http.StatusBadRequest, model.ErrorResponse{
+						Status:  "error",
+						Message: "Invalid request body: " + err.Error(),
+						Time:    time.Now().Format(time.RFC3339),
+					})
+					return
+				}
+				// Items before this point are still stored; report where parsing stopped
+				w.reject(index, err.Error()+"; remaining items were not processed", nil)
+				break
+			}
+
+			if index >= maxBatchSize {
+				w.reject(index, fmt.Sprintf("Batch exceeds maximum of %d items; remaining items were not processed", maxBatchSize), nil)
+				break
+			}
+
+			var req model.CreateMessageRequest
+			if err := json.Unmarshal(raw, &req); err != nil {
+				w.reject(index, "Invalid item: "+err.Error(), nil)
+				continue
+			}
+
+			if errs := validator.ValidateCreateRequest(req); len(errs) > 0 {
+				w.reject(index, "Validation failed", errs)
+				continue
+			}
+
+			m := newMessage(req)
+			if key != "" {
+				m.IdempotencyKey = key + ":" + strconv.Itoa(index)
+			}
+			w.add(index, m)
+		}
+		w.flush()
+
+		// Chunk fallbacks and replays report items late, keep the response ordered by index
+		sort.Slice(resp.Accepted, func(i, j int) bool {
+			return resp.Accepted[i].Index < resp.Accepted[j].Index
+		})
+		sort.Slice(resp.Rejected, func(i, j int) bool {
+			return resp.Rejected[i].Index < resp.Rejected[j].Index
+		})
+		resp.AcceptedCount = len(resp.Accepted)
+		resp.RejectedCount = len(resp.Rejected)
+		c.JSON(http.StatusOK, resp)
+	}
+}
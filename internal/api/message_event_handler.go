@@ -0,0 +1,62 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"insider-message-sender/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// messageEventStore is the part of the message repository the timeline needs
+type messageEventStore interface {
+	GetByID(ctx context.Context, id int64) (*model.Message, error)
+	FetchEvents(ctx context.Context, messageID int64) ([]model.MessageEvent, error)
+}
+
+// @Summary Get the timeline of a message
+// @Description Returns every recorded transition of a message, oldest first: created, claimed, each delivery attempt
+// @Description with the provider's HTTP status and latency, retries, sent, failed, requeued and delivery reports.
+// @Tags Messages
+// @Produce json
+// @Param id path int true "Message ID"
+// @Success 200 {object} model.MessageEventsResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/messages/{id}/events [get]
+func GetMessageEvents(repo messageEventStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil || id <= 0 {
+			respondError(c, http.StatusBadRequest, "Invalid message id")
+			return
+		}
+
+		// A message always has a created event, so an empty timeline would only hide a wrong id
+		_, err = repo.GetByID(c.Request.Context(), id)
+		if errors.Is(err, sql.ErrNoRows) {
+			respondError(c, http.StatusNotFound, "Message not found")
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to fetch message %d: %v", id, err)
+			respondError(c, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+
+		events, err := repo.FetchEvents(c.Request.Context(), id)
+		if err != nil {
+			log.Printf("Failed to fetch events of message %d: %v", id, err)
+			respondError(c, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+
+		c.JSON(http.StatusOK, model.MessageEventsResponse{Data: events})
+	}
+}
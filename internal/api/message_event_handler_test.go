@@ -0,0 +1,81 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"insider-message-sender/internal/constants"
+	"insider-message-sender/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeMessageStore serves the messages it holds, the way the repository reads them
+type fakeMessageStore struct {
+	messages map[int64]model.Message
+	events   []model.MessageEvent
+	requeues []model.Requeue
+}
+
+func (f *fakeMessageStore) GetByID(_ context.Context, id int64) (*model.Message, error) {
+	m, ok := f.messages[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &m, nil
+}
+
+func (f *fakeMessageStore) FetchEvents(_ context.Context, messageID int64) ([]model.MessageEvent, error) {
+	events := []model.MessageEvent{}
+	for _, e := range f.events {
+		if e.MessageID == messageID {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (f *fakeMessageStore) FetchRequeues(_ context.Context, messageID int64) ([]model.Requeue, error) {
+	requeues := []model.Requeue{}
+	for _, rq := range f.requeues {
+		if rq.MessageID == messageID {
+			requeues = append(requeues, rq)
+		}
+	}
+	return requeues, nil
+}
+
+func serve(t *testing.T, route string, h gin.HandlerFunc, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET(route, h)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestGetMessageEvents(t *testing.T) {
+	store := &fakeMessageStore{
+		messages: map[int64]model.Message{42: {ID: 42}},
+		events:   []model.MessageEvent{{ID: 1, MessageID: 42, Type: constants.MessageEventCreated}},
+	}
+	h := GetMessageEvents(store)
+
+	w := serve(t, "/messages/:id/events", h, "/messages/42/events")
+	var resp model.MessageEventsResponse
+	if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &resp) != nil || len(resp.Data) != 1 {
+		t.Fatalf("GET existing message events = %d %s, want 200 with one event", w.Code, w.Body)
+	}
+
+	if w := serve(t, "/messages/:id/events", h, "/messages/43/events"); w.Code != http.StatusNotFound {
+		t.Fatalf("GET unknown message events = %d %s, want 404", w.Code, w.Body)
+	}
+	if w := serve(t, "/messages/:id/events", h, "/messages/abc/events"); w.Code != http.StatusBadRequest {
+		t.Fatalf("GET events with a malformed id = %d, want 400", w.Code)
+	}
+}
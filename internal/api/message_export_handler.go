@@ -0,0 +1,216 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"insider-message-sender/internal/constants"
+	"insider-message-sender/internal/model"
+	"insider-message-sender/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportFlushEvery is how many rows are written between flushes of the response
+const exportFlushEvery = 500
+
+// exportColumns is the CSV header, matching the fields written by csvExporter
+var exportColumns = []string{
+	"id", "phone_number", "content", "status", "priority", "created_at", "send_at", "sent_at", "failed_at", "expires_at",
+	"attempt_count", "last_error", "error_class", "provider_error_code", "provider", "provider_message_id",
+	"last_status_code", "delivery_reported_at", "delivery_reason",
+}
+
+// exporter writes messages to an export response in one format
+type exporter interface {
+	Begin() error
+	Write(m model.Message) error
+	Flush() error
+}
+
+type csvExporter struct {
+	w *csv.Writer
+}
+
+func (e *csvExporter) Begin() error {
+	return e.w.Write(exportColumns)
+}
+
+func (e *csvExporter) Write(m model.Message) error {
+	return e.w.Write([]string{
+		strconv.FormatInt(m.ID, 10),
+		m.PhoneNumber,
+		csvSafe(m.Content),
+		m.Status,
+		m.Priority,
+		m.CreatedAt.Format(time.RFC3339),
+		m.SendAt.Format(time.RFC3339),
+		formatTime(m.SentAt),
+		formatTime(m.FailedAt),
+		formatTime(m.ExpiresAt),
+		strconv.Itoa(m.AttemptCount),
+		csvSafe(m.LastError),
+		m.ErrorClass,
+		csvSafe(m.ProviderErrorCode),
+		m.Provider,
+		csvSafe(m.ProviderMessageID),
+		formatInt(m.LastStatusCode),
+		formatTime(m.DeliveryReportedAt),
+		csvSafe(m.DeliveryReason),
+	})
+}
+
+// csvSafe keeps spreadsheets from evaluating free text written by clients or providers as a formula, by
+// prefixing cells that start with a formula trigger with a single quote
+func csvSafe(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
+
+func (e *csvExporter) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type ndjsonExporter struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonExporter) Begin() error {
+	return nil
+}
+
+func (e *ndjsonExporter) Write(m model.Message) error {
+	return e.enc.Encode(model.SentMessageResponseData(m))
+}
+
+func (e *ndjsonExporter) Flush() error {
+	return nil
+}
+
+// @Summary Export messages
+// @Description Streams every message matching the filters of GET /api/v1/messages as CSV or NDJSON with chunked
+// @Description transfer encoding. Rows are written as they are read from the database, so exports of any size use
+// @Description constant memory. Sorted by id unless sort is given; cursor and limit do not apply.
+// @Description If the export fails midway the response ends early, so check that the row count is as expected.
+// @Tags Messages
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param format query string false "Export format" Enums(csv, ndjson) default(csv)
+// @Param status query []string false "Statuses to include, repeated or comma-separated" collectionFormat(multi)
+// @Param phone_number query string false "Exact phone number"
+// @Param content query string false "Substring of the content, ignoring case"
+// @Param created_from query string false "Created at or after (RFC 3339)"
+// @Param created_to query string false "Created before (RFC 3339)"
+// @Param sent_from query string false "Sent at or after (RFC 3339)"
+// @Param sent_to query string false "Sent before (RFC 3339)"
+// @Param provider query string false "Provider of the last delivery attempt"
+// @Param sort query string false "id, created_at, send_at, sent_at, failed_at or expires_at; prefix with - for descending order" default(id)
+// @Success 200 {string} string "CSV or NDJSON stream"
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 422 {object} model.ValidationErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/messages/export [get]
+func ExportMessages(repo *repository.MessageRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format := c.DefaultQuery("format", constants.ExportFormatCSV)
+		var contentType string
+		switch format {
+		case constants.ExportFormatCSV:
+			contentType = "text/csv; charset=utf-8"
+		case constants.ExportFormatNDJSON:
+			contentType = "application/x-ndjson"
+		default:
+			respondError(c, http.StatusBadRequest, "Unsupported format, use csv or ndjson")
+			return
+		}
+
+		req, ok := bindListMessagesRequest(c, nil, constants.MessageSortID)
+		if !ok {
+			return
+		}
+
+		var exp exporter
+		if format == constants.ExportFormatCSV {
+			exp = &csvExporter{w: csv.NewWriter(c.Writer)}
+		} else {
+			exp = &ndjsonExporter{enc: json.NewEncoder(c.Writer)}
+		}
+
+		// Headers are only sent with the first row, so a query that fails up front still gets an error response
+		started := false
+		start := func() error {
+			started = true
+			c.Header("Content-Type", contentType)
+			c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="messages-%s.%s"`,
+				time.Now().UTC().Format("20060102T150405Z"), format))
+			c.Status(http.StatusOK)
+			return exp.Begin()
+		}
+
+		rows := 0
+		err := repo.StreamMessages(c.Request.Context(), messageFilter(req), parseSort(req.Sort), func(m model.Message) error {
+			if !started {
+				if err := start(); err != nil {
+					return err
+				}
+			}
+			if err := exp.Write(m); err != nil {
+				return err
+			}
+			rows++
+			if rows%exportFlushEvery == 0 {
+				return flushExport(c, exp)
+			}
+			return nil
+		})
+		if err == nil && !started {
+			err = start()
+		}
+		if err == nil {
+			err = flushExport(c, exp)
+		}
+
+		switch {
+		case err == nil:
+			log.Printf("Exported %d messages as %s", rows, format)
+		case !started:
+			log.Printf("Failed to export messages: %v", err)
+			respondError(c, http.StatusInternalServerError, "Internal server error")
+		default:
+			// The status line is already sent; ending the stream early is all that is left
+			log.Printf("Export of messages as %s stopped after %d rows: %v", format, rows, err)
+		}
+	}
+}
+
+// flushExport pushes buffered rows to the client as a chunk
+func flushExport(c *gin.Context, exp exporter) error {
+	if err := exp.Flush(); err != nil {
+		return err
+	}
+	c.Writer.Flush()
+	return nil
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func formatInt(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
@@ -1,111 +1,238 @@
 package api
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
+	"time"
 
+	"insider-message-sender/internal/cache"
 	"insider-message-sender/internal/model"
 	"insider-message-sender/internal/repository"
+	"insider-message-sender/internal/validator"
 
 	"github.com/gin-gonic/gin"
 )
 
-// @Summary Get list of sent messages (with pagination)
+// @Summary Create a new message
+// @Description Validates and stores a message with pending status so the scheduler picks it up on a later tick.
+// @Description Repeating a request with the same Idempotency-Key returns the stored message with status 200
+// @Description and the Idempotent-Replayed header instead of creating a duplicate.
 // @Tags Messages
+// @Accept json
 // @Produce json
-// @Param limit query int false "Number of messages to return" default(10)
-// @Param offset query int false "Number of messages to skip" default(0)
-// @Success 200 {object} model.SentMessagesResponse
-// @Router /api/v1/messages/sent [get]
-func GetSentMessages(repo *repository.MessageRepository) gin.HandlerFunc {
+// @Param message body model.CreateMessageRequest true "Message to send"
+// @Param Idempotency-Key header string false "Client key that makes retries of this request safe, at most 255 characters"
+// @Success 201 {object} model.CreateMessageResponse
+// @Success 200 {object} model.CreateMessageResponse "Replayed: a message with this Idempotency-Key already exists"
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 422 {object} model.ValidationErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/messages [post]
+func CreateMessage(repo *repository.MessageRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
-		if err != nil || limit <= 0 {
-			limit = 10
+		var req model.CreateMessageRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Status:  "error",
+				Message: "Invalid request body: " + err.Error(),
+				Time:    time.Now().Format(time.RFC3339),
+			})
+			return
 		}
 
-		offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
-		if err != nil || offset < 0 {
-			offset = 0
+		if errs := validator.ValidateCreateRequest(req); len(errs) > 0 {
+			c.JSON(http.StatusUnprocessableEntity, model.ValidationErrorResponse{
+				Status:  "error",
+				Message: "Validation failed",
+				Errors:  errs,
+				Time:    time.Now().Format(time.RFC3339),
+			})
+			return
 		}
 
-		msgs, err := repo.FetchSent(limit, offset)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		key, ok := idempotencyKey(c)
+		if !ok {
 			return
 		}
 
-		total, err := repo.CountSent()
+		m := newMessage(req)
+		m.IdempotencyKey = key
+		err := repo.Create(c.Request.Context(), &m)
+		if errors.Is(err, repository.ErrDuplicateIdempotencyKey) {
+			replayMessage(c, repo, m)
+			return
+		}
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			log.Printf("Failed to create message: %v", err)
+			c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Status:  "error",
+				Message: "Internal server error",
+				Time:    time.Now().Format(time.RFC3339),
+			})
 			return
 		}
 
-		resp := model.SentMessagesResponse{
-			Data: make([]model.SentMessageResponseData, len(msgs)),
-			Pagination: model.Pagination{
-				Limit:   limit,
-				Offset:  offset,
-				Count:   len(msgs),
-				Total:   total,
-				HasMore: offset+limit < total,
-			},
-		}
+		c.JSON(http.StatusCreated, model.CreateMessageResponse{Data: messageResponseData(m)})
+	}
+}
 
-		for i, m := range msgs {
-			resp.Data[i] = model.SentMessageResponseData(m)
-		}
+// replayMessage answers a create request whose idempotency key is already stored with the stored message,
+// as long as the request asks for the same message
+func replayMessage(c *gin.Context, repo *repository.MessageRepository, m model.Message) {
+	stored, err := repo.FetchByIdempotencyKeys(c.Request.Context(), []string{m.IdempotencyKey})
+	if err != nil {
+		log.Printf("Failed to fetch message with idempotency key %q: %v", m.IdempotencyKey, err)
+		respondError(c, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	existing, found := stored[m.IdempotencyKey]
+	if !found {
+		// The conflicting row was removed in the meantime; let the client retry
+		respondError(c, http.StatusConflict, "Idempotency key conflict, retry the request")
+		return
+	}
+	if !sameMessage(existing, m) {
+		respondError(c, http.StatusConflict, "Idempotency key was already used for a different message")
+		return
+	}
+
+	c.Header(idempotentReplayedHeader, "true")
+	c.JSON(http.StatusOK, model.CreateMessageResponse{Data: messageResponseData(existing)})
+}
+
+func messageResponseData(m model.Message) model.MessageResponseData {
+	return model.MessageResponseData{
+		ID:             m.ID,
+		PhoneNumber:    m.PhoneNumber,
+		Content:        m.Content,
+		Status:         m.Status,
+		Priority:       m.Priority,
+		SendAt:         m.SendAt,
+		ExpiresAt:      m.ExpiresAt,
+		IdempotencyKey: m.IdempotencyKey,
+	}
+}
 
-		c.JSON(http.StatusOK, resp)
+// newMessage builds the message to store from a validated create request
+func newMessage(req model.CreateMessageRequest) model.Message {
+	m := model.Message{
+		PhoneNumber: req.PhoneNumber,
+		Content:     req.Content,
+		Priority:    req.Priority,
+	}
+	if req.SendAt != nil {
+		m.SendAt = *req.SendAt
 	}
+	m.ExpiresAt = req.ExpiresAt
+	return m
 }
 
-// @Summary Get list of failed messages (with pagination)
+// @Summary Get a message
+// @Description Returns the full record of a message, including its status, send timestamp and delivery details.
 // @Tags Messages
 // @Produce json
-// @Param limit query int false "Number of messages to return" default(10)
-// @Param offset query int false "Number of messages to skip" default(0)
-// @Success 200 {object} model.SentMessagesResponse
-// @Router /api/v1/messages/failed [get]
-func GetFailedMessages(repo *repository.MessageRepository) gin.HandlerFunc {
+// @Param id path int true "Message ID"
+// @Success 200 {object} model.MessageDetailResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/messages/{id} [get]
+func GetMessage(repo *repository.MessageRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
-		if err != nil || limit <= 0 {
-			limit = 10
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil || id <= 0 {
+			respondError(c, http.StatusBadRequest, "Invalid message id")
+			return
 		}
 
-		offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
-		if err != nil || offset < 0 {
-			offset = 0
+		m, err := repo.GetByID(c.Request.Context(), id)
+		if errors.Is(err, sql.ErrNoRows) {
+			respondError(c, http.StatusNotFound, "Message not found")
+			return
 		}
-
-		msgs, err := repo.FetchFailed(limit, offset)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			log.Printf("Failed to fetch message %d: %v", id, err)
+			respondError(c, http.StatusInternalServerError, "Internal server error")
 			return
 		}
 
-		total, err := repo.CountFailed()
+		c.JSON(http.StatusOK, model.MessageDetailResponse{Data: model.SentMessageResponseData(*m)})
+	}
+}
+
+// @Summary Look a message up by provider messageId
+// @Description Returns the full record of the message the provider accepted under messageId, for example to match a
+// @Description delivery report. The messageId is resolved through the Redis entry written on send and through the
+// @Description database once that entry expired. If several providers issued the same messageId, pass provider to
+// @Description pick one; otherwise the most recent message wins.
+// @Tags Messages
+// @Produce json
+// @Param messageId path string true "Message id returned by the provider"
+// @Param provider query string false "Provider that issued the messageId"
+// @Success 200 {object} model.MessageDetailResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/messages/by-provider-id/{messageId} [get]
+func GetMessageByProviderID(repo *repository.MessageRepository, redisClient *cache.RedisClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		messageID := c.Param("messageId")
+
+		m, err := lookupSentMessage(c.Request.Context(), repo, redisClient, c.Query("provider"), messageID)
+		if errors.Is(err, sql.ErrNoRows) {
+			respondError(c, http.StatusNotFound, "Message not found")
+			return
+		}
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			log.Printf("Failed to look up provider messageId %s: %v", messageID, err)
+			respondError(c, http.StatusInternalServerError, "Internal server error")
 			return
 		}
 
-		resp := model.SentMessagesResponse{
-			Data: make([]model.SentMessageResponseData, len(msgs)),
-			Pagination: model.Pagination{
-				Limit:   limit,
-				Offset:  offset,
-				Count:   len(msgs),
-				Total:   total,
-				HasMore: offset+limit < total,
-			},
+		c.JSON(http.StatusOK, model.MessageDetailResponse{Data: model.SentMessageResponseData(*m)})
+	}
+}
+
+// lookupSentMessage resolves a provider messageId to the message through the Redis entry written when the message
+// was sent, falling back to the database once the entry expired. It returns sql.ErrNoRows if nothing matches.
+func lookupSentMessage(ctx context.Context, repo *repository.MessageRepository, redisClient *cache.RedisClient,
+	providerName, providerMessageID string) (*model.Message, error) {
+	o, err := redisClient.LookupSent(ctx, providerMessageID)
+	if err != nil {
+		log.Printf("Failed to look up messageId %s in Redis, falling back to the database: %v", providerMessageID, err)
+	}
+	if o != nil && (providerName == "" || o.Provider == providerName) {
+		m, err := repo.GetByID(ctx, o.MessageID)
+		if !errors.Is(err, sql.ErrNoRows) {
+			return m, err
 		}
+	}
+	return repo.GetByProviderMessageID(ctx, providerName, providerMessageID)
+}
 
-		for i, m := range msgs {
-			resp.Data[i] = model.SentMessageResponseData(m)
+// @Summary Get message counters
+// @Description Returns the number of messages in every status.
+// @Tags Messages
+// @Produce json
+// @Success 200 {object} model.MessageStatsResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/messages/stats [get]
+func GetMessageStats(repo *repository.MessageRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		counts, err := repo.CountByStatus(c.Request.Context())
+		if err != nil {
+			log.Printf("Failed to count messages: %v", err)
+			respondError(c, http.StatusInternalServerError, "Internal server error")
+			return
 		}
 
-		c.JSON(http.StatusOK, resp)
+		c.JSON(http.StatusOK, model.MessageStatsResponse{
+			Counts: counts,
+			Time:   time.Now().Format(time.RFC3339),
+		})
 	}
 }
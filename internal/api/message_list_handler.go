@@ -0,0 +1,295 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"insider-message-sender/internal/constants"
+	"insider-message-sender/internal/model"
+	"insider-message-sender/internal/repository"
+	"insider-message-sender/internal/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultListLimit = 10
+	maxListLimit     = 1000
+)
+
+// errInvalidCursor is returned for cursors that are malformed or were issued for another sort order
+var errInvalidCursor = errors.New("invalid cursor")
+
+// @Summary Search messages
+// @Description Lists messages matching every given filter with keyset pagination: pass next_cursor from a response
+// @Description as cursor to fetch the following page. A cursor is only valid with the sort it was issued for.
+// @Description Sorting by sent_at, failed_at or expires_at leaves out messages where that field is not set.
+// @Tags Messages
+// @Produce json
+// @Param status query []string false "Statuses to include, repeated or comma-separated" collectionFormat(multi)
+// @Param phone_number query string false "Exact phone number"
+// @Param content query string false "Substring of the content, ignoring case"
+// @Param created_from query string false "Created at or after (RFC 3339)"
+// @Param created_to query string false "Created before (RFC 3339)"
+// @Param sent_from query string false "Sent at or after (RFC 3339)"
+// @Param sent_to query string false "Sent before (RFC 3339)"
+// @Param provider query string false "Provider of the last delivery attempt"
+// @Param sort query string false "id, created_at, send_at, sent_at, failed_at or expires_at; prefix with - for descending order" default(-created_at)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param limit query int false "Number of messages to return, at most 1000" default(10)
+// @Success 200 {object} model.MessagesResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 422 {object} model.ValidationErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/messages [get]
+func ListMessages(repo *repository.MessageRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		listMessages(c, repo, nil, "-"+constants.MessageSortCreatedAt, false)
+	}
+}
+
+// @Summary Get list of sent messages (with pagination)
+// @Description Messages a provider accepted, including those with a delivery report, most recently sent first.
+// @Description Accepts the filters of GET /api/v1/messages except status. Pages can be fetched by cursor or by offset;
+// @Description pagination.offset and pagination.total are always reported.
+// @Tags Messages
+// @Produce json
+// @Param limit query int false "Number of messages to return" default(10)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param offset query int false "Number of messages to skip; cannot be combined with cursor" default(0)
+// @Param sort query string false "Sort field, prefix with - for descending order" default(-sent_at)
+// @Success 200 {object} model.MessagesResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 422 {object} model.ValidationErrorResponse
+// @Router /api/v1/messages/sent [get]
+func GetSentMessages(repo *repository.MessageRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		listMessages(c, repo, constants.SentStatusValues(), "-"+constants.MessageSortSentAt, true)
+	}
+}
+
+// @Summary Get list of failed messages (with pagination)
+// @Description Failed messages, most recently failed first. Accepts the filters of GET /api/v1/messages except status.
+// @Description Pages can be fetched by cursor or by offset; pagination.offset and pagination.total are always reported.
+// @Tags Messages
+// @Produce json
+// @Param limit query int false "Number of messages to return" default(10)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param offset query int false "Number of messages to skip; cannot be combined with cursor" default(0)
+// @Param sort query string false "Sort field, prefix with - for descending order" default(-failed_at)
+// @Success 200 {object} model.MessagesResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 422 {object} model.ValidationErrorResponse
+// @Router /api/v1/messages/failed [get]
+func GetFailedMessages(repo *repository.MessageRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		listMessages(c, repo, []string{constants.MessageStatusFailed}, "-"+constants.MessageSortFailedAt, true)
+	}
+}
+
+// @Summary Get list of expired messages (with pagination)
+// @Description Messages that passed their expires_at before they could be sent, most recently expired first.
+// @Description Accepts the filters of GET /api/v1/messages except status. Pages can be fetched by cursor or by offset;
+// @Description pagination.offset and pagination.total are always reported.
+// @Tags Messages
+// @Produce json
+// @Param limit query int false "Number of messages to return" default(10)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param offset query int false "Number of messages to skip; cannot be combined with cursor" default(0)
+// @Param sort query string false "Sort field, prefix with - for descending order" default(-expires_at)
+// @Success 200 {object} model.MessagesResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 422 {object} model.ValidationErrorResponse
+// @Router /api/v1/messages/expired [get]
+func GetExpiredMessages(repo *repository.MessageRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		listMessages(c, repo, []string{constants.MessageStatusExpired}, "-"+constants.MessageSortExpiresAt, true)
+	}
+}
+
+// listMessages serves one page of a message search. Non-nil statuses replace the status filter of the request.
+// Routes with withOffset keep the offset pagination and total they offered before cursors were introduced.
+func listMessages(c *gin.Context, repo *repository.MessageRepository, statuses []string, defaultSort string, withOffset bool) {
+	req, ok := bindListMessagesRequest(c, statuses, defaultSort)
+	if !ok {
+		return
+	}
+	if req.Offset != nil && !withOffset {
+		respondError(c, http.StatusBadRequest, "offset is not supported by this endpoint, use cursor")
+		return
+	}
+
+	sort := parseSort(req.Sort)
+	var after *model.MessageCursor
+	if req.Cursor != "" {
+		cursor, err := decodeCursor(req.Cursor, req.Sort)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "Invalid cursor")
+			return
+		}
+		after = cursor
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	limit = min(limit, maxListLimit)
+
+	offset := 0
+	if req.Offset != nil {
+		offset = *req.Offset
+	}
+
+	// One extra row tells whether another page follows
+	filter := messageFilter(req)
+	msgs, err := repo.SearchMessages(c.Request.Context(), filter, sort, after, limit+1, offset)
+	if err != nil {
+		log.Printf("Failed to search messages: %v", err)
+		respondError(c, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	hasMore := len(msgs) > limit
+	if hasMore {
+		msgs = msgs[:limit]
+	}
+
+	resp := model.MessagesResponse{
+		Data: make([]model.SentMessageResponseData, len(msgs)),
+		Pagination: model.Pagination{
+			Limit:   limit,
+			Count:   len(msgs),
+			HasMore: hasMore,
+		},
+	}
+	if withOffset {
+		total, err := repo.CountMessages(c.Request.Context(), filter, sort)
+		if err != nil {
+			log.Printf("Failed to count messages: %v", err)
+			respondError(c, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+		resp.Pagination.Offset = &offset
+		resp.Pagination.Total = &total
+	}
+	for i, m := range msgs {
+		resp.Data[i] = model.SentMessageResponseData(m)
+	}
+	if hasMore {
+		resp.Pagination.NextCursor = encodeCursor(req.Sort, msgs[len(msgs)-1])
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// bindListMessagesRequest reads and validates the search query parameters, responding with an error and
+// returning false when they are invalid
+func bindListMessagesRequest(c *gin.Context, statuses []string, defaultSort string) (model.ListMessagesRequest, bool) {
+	var req model.ListMessagesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid query parameters: "+err.Error())
+		return req, false
+	}
+
+	if statuses != nil {
+		req.Status = statuses
+	} else {
+		req.Status = splitList(req.Status)
+	}
+	if req.Sort == "" {
+		req.Sort = defaultSort
+	}
+
+	if errs := validator.ValidateListMessagesRequest(req); len(errs) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, model.ValidationErrorResponse{
+			Status:  "error",
+			Message: "Validation failed",
+			Errors:  errs,
+			Time:    time.Now().Format(time.RFC3339),
+		})
+		return req, false
+	}
+	return req, true
+}
+
+func messageFilter(req model.ListMessagesRequest) model.MessageFilter {
+	return model.MessageFilter{
+		Statuses:    req.Status,
+		PhoneNumber: req.PhoneNumber,
+		Content:     req.Content,
+		CreatedFrom: req.CreatedFrom,
+		CreatedTo:   req.CreatedTo,
+		SentFrom:    req.SentFrom,
+		SentTo:      req.SentTo,
+		Provider:    req.Provider,
+	}
+}
+
+// splitList flattens repeated and comma-separated query values
+func splitList(values []string) []string {
+	var out []string
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+	}
+	return out
+}
+
+func parseSort(s string) model.MessageSort {
+	if field, ok := strings.CutPrefix(s, "-"); ok {
+		return model.MessageSort{Field: field, Desc: true}
+	}
+	return model.MessageSort{Field: s}
+}
+
+// cursorToken is the content of an opaque page cursor. The sort is kept so that a cursor is never applied
+// to an order it does not describe.
+type cursorToken struct {
+	Sort  string     `json:"s"`
+	Value *time.Time `json:"v,omitempty"`
+	ID    int64      `json:"id"`
+}
+
+// encodeCursor returns the cursor of the page following m in the given sort
+func encodeCursor(sort string, m model.Message) string {
+	token := cursorToken{Sort: sort, ID: m.ID}
+	switch parseSort(sort).Field {
+	case constants.MessageSortCreatedAt:
+		token.Value = &m.CreatedAt
+	case constants.MessageSortSendAt:
+		token.Value = &m.SendAt
+	case constants.MessageSortSentAt:
+		token.Value = m.SentAt
+	case constants.MessageSortFailedAt:
+		token.Value = m.FailedAt
+	case constants.MessageSortExpiresAt:
+		token.Value = m.ExpiresAt
+	}
+
+	payload, _ := json.Marshal(token)
+	return base64.RawURLEncoding.EncodeToString(payload)
+}
+
+func decodeCursor(cursor, sort string) (*model.MessageCursor, error) {
+	payload, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	var token cursorToken
+	if err := json.Unmarshal(payload, &token); err != nil || token.Sort != sort {
+		return nil, errInvalidCursor
+	}
+	if token.Value == nil && parseSort(sort).Field != constants.MessageSortID {
+		return nil, errInvalidCursor
+	}
+	return &model.MessageCursor{Value: token.Value, ID: token.ID}, nil
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"encoding/base64"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"insider-message-sender/internal/model"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	sentAt := time.Date(2025, 10, 19, 7, 39, 12, 123456000, time.UTC)
+	m := model.Message{ID: 42, CreatedAt: sentAt.Add(-time.Hour), SentAt: &sentAt}
+
+	tests := []struct {
+		sort      string
+		wantValue *time.Time
+	}{
+		{"-sent_at", &sentAt},
+		{"created_at", &m.CreatedAt},
+		{"id", nil},
+		{"-id", nil},
+	}
+	for _, tt := range tests {
+		cursor, err := decodeCursor(encodeCursor(tt.sort, m), tt.sort)
+		if err != nil {
+			t.Fatalf("decodeCursor for %s: %v", tt.sort, err)
+		}
+		if cursor.ID != 42 {
+			t.Errorf("%s: cursor id = %d, want 42", tt.sort, cursor.ID)
+		}
+		switch {
+		case tt.wantValue == nil && cursor.Value != nil:
+			t.Errorf("%s: cursor value = %s, want none", tt.sort, cursor.Value)
+		case tt.wantValue != nil && (cursor.Value == nil || !cursor.Value.Equal(*tt.wantValue)):
+			t.Errorf("%s: cursor value = %v, want %s", tt.sort, cursor.Value, tt.wantValue)
+		}
+	}
+}
+
+func TestDecodeCursorRejectsForeignOrMalformedCursors(t *testing.T) {
+	sentAt := time.Date(2025, 10, 19, 7, 39, 12, 0, time.UTC)
+	m := model.Message{ID: 42, SentAt: &sentAt}
+
+	tests := []struct {
+		name   string
+		cursor string
+		sort   string
+	}{
+		{"other sort", encodeCursor("-sent_at", m), "sent_at"},
+		{"not base64", "not a cursor!", "-sent_at"},
+		{"not json", base64.RawURLEncoding.EncodeToString([]byte("42")), "-sent_at"},
+		// A message without the sort field cannot be resumed from
+		{"missing value", encodeCursor("-failed_at", m), "-failed_at"},
+	}
+	for _, tt := range tests {
+		if _, err := decodeCursor(tt.cursor, tt.sort); !errors.Is(err, errInvalidCursor) {
+			t.Errorf("%s: decodeCursor = %v, want errInvalidCursor", tt.name, err)
+		}
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	got := splitList([]string{"sent, failed", "", "expired,", " pending "})
+	want := []string{"sent", "failed", "expired", "pending"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("splitList = %q, want %q", got, want)
+	}
+}
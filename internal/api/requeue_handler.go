@@ -0,0 +1,194 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"insider-message-sender/internal/constants"
+	"insider-message-sender/internal/model"
+	"insider-message-sender/internal/repository"
+	"insider-message-sender/internal/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultRequeueLimit = 1000
+
+// requeueHistoryStore is the part of the message repository the requeue audit trail needs
+type requeueHistoryStore interface {
+	GetByID(ctx context.Context, id int64) (*model.Message, error)
+	FetchRequeues(ctx context.Context, messageID int64) ([]model.Requeue, error)
+}
+
+// @Summary Requeue failed messages
+// @Description Sends failed messages back to pending with a clean attempt state, selected either by a list of ids
+// @Description or by a filter on failure time and error class. Filter requests requeue at most `limit` messages
+// @Description (1000 by default); repeat the request until requeued_count is 0 to drain a larger set.
+// @Description Every requeue is recorded in the audit trail. Ids that are not failed are skipped.
+// @Tags Messages
+// @Accept json
+// @Produce json
+// @Param request body model.RequeueMessagesRequest true "Messages to requeue"
+// @Success 200 {object} model.RequeueResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 422 {object} model.ValidationErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/messages/failed/requeue [post]
+func RequeueFailedMessages(repo *repository.MessageRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req model.RequeueMessagesRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, "Invalid request body: "+err.Error())
+			return
+		}
+
+		if errs := validator.ValidateRequeueRequest(req); len(errs) > 0 {
+			c.JSON(http.StatusUnprocessableEntity, model.ValidationErrorResponse{
+				Status:  "error",
+				Message: "Validation failed",
+				Errors:  errs,
+				Time:    time.Now().Format(time.RFC3339),
+			})
+			return
+		}
+
+		filter := model.RequeueFilter{
+			IDs:        req.IDs,
+			FailedFrom: req.FailedFrom,
+			FailedTo:   req.FailedTo,
+			ErrorClass: req.ErrorClass,
+			Limit:      req.Limit,
+		}
+		if len(filter.IDs) == 0 && filter.Limit == 0 {
+			filter.Limit = defaultRequeueLimit
+		}
+
+		ids, err := repo.RequeueFailed(c.Request.Context(), filter, requestedBy(c, req.RequestedBy), req.Reason)
+		if err != nil {
+			log.Printf("Failed to requeue failed messages: %v", err)
+			respondError(c, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+		log.Printf("Requeued %d failed messages", len(ids))
+
+		c.JSON(http.StatusOK, model.RequeueResponse{
+			RequeuedCount: len(ids),
+			IDs:           ids,
+			Time:          time.Now().Format(time.RFC3339),
+		})
+	}
+}
+
+// @Summary Requeue a failed message
+// @Description Sends a single failed message back to pending with a clean attempt state and records it in the audit trail.
+// @Tags Messages
+// @Accept json
+// @Produce json
+// @Param id path int true "Message ID"
+// @Param request body model.RequeueMessageRequest false "Audit details"
+// @Success 200 {object} model.RequeueResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/messages/{id}/requeue [post]
+func RequeueMessage(repo *repository.MessageRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil || id <= 0 {
+			respondError(c, http.StatusBadRequest, "Invalid message id")
+			return
+		}
+
+		// The body only carries audit details, so it may be omitted
+		var req model.RequeueMessageRequest
+		if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			respondError(c, http.StatusBadRequest, "Invalid request body: "+err.Error())
+			return
+		}
+
+		ids, err := repo.RequeueFailed(c.Request.Context(), model.RequeueFilter{IDs: []int64{id}}, requestedBy(c, req.RequestedBy), req.Reason)
+		if err != nil {
+			log.Printf("Failed to requeue message %d: %v", id, err)
+			respondError(c, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+
+		if len(ids) == 0 {
+			m, err := repo.GetByID(c.Request.Context(), id)
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				respondError(c, http.StatusNotFound, "Message not found")
+			case err != nil:
+				log.Printf("Failed to fetch message %d: %v", id, err)
+				respondError(c, http.StatusInternalServerError, "Internal server error")
+			case m.Status != constants.MessageStatusFailed:
+				respondError(c, http.StatusConflict, "Only failed messages can be requeued, message is "+m.Status)
+			default:
+				// Still failed but locked by a concurrent requeue
+				respondError(c, http.StatusConflict, "Message is being requeued by another request")
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, model.RequeueResponse{
+			RequeuedCount: len(ids),
+			IDs:           ids,
+			Time:          time.Now().Format(time.RFC3339),
+		})
+	}
+}
+
+// @Summary Get the requeue audit trail of a message
+// @Tags Messages
+// @Produce json
+// @Param id path int true "Message ID"
+// @Success 200 {object} model.RequeueHistoryResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/messages/{id}/requeues [get]
+func GetMessageRequeues(repo requeueHistoryStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil || id <= 0 {
+			respondError(c, http.StatusBadRequest, "Invalid message id")
+			return
+		}
+
+		// An empty trail is a valid answer for a message that was never requeued, but not for one that does not exist
+		_, err = repo.GetByID(c.Request.Context(), id)
+		if errors.Is(err, sql.ErrNoRows) {
+			respondError(c, http.StatusNotFound, "Message not found")
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to fetch message %d: %v", id, err)
+			respondError(c, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+
+		requeues, err := repo.FetchRequeues(c.Request.Context(), id)
+		if err != nil {
+			log.Printf("Failed to fetch requeues of message %d: %v", id, err)
+			respondError(c, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+
+		c.JSON(http.StatusOK, model.RequeueHistoryResponse{Data: requeues})
+	}
+}
+
+// requestedBy identifies who asked for a requeue, falling back to the client address
+func requestedBy(c *gin.Context, given string) string {
+	if given != "" {
+		return given
+	}
+	return c.ClientIP()
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"insider-message-sender/internal/model"
+)
+
+func TestGetMessageRequeues(t *testing.T) {
+	store := &fakeMessageStore{
+		messages: map[int64]model.Message{42: {ID: 42}, 43: {ID: 43}},
+		requeues: []model.Requeue{{ID: 1, MessageID: 42, RequestedBy: "ops"}},
+	}
+	h := GetMessageRequeues(store)
+
+	for _, tt := range []struct {
+		path string
+		want int
+	}{
+		{"/messages/42/requeues", 1},
+		{"/messages/43/requeues", 0},
+	} {
+		w := serve(t, "/messages/:id/requeues", h, tt.path)
+		var resp model.RequeueHistoryResponse
+		if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &resp) != nil || len(resp.Data) != tt.want {
+			t.Fatalf("GET %s = %d %s, want 200 with %d requeues", tt.path, w.Code, w.Body, tt.want)
+		}
+	}
+
+	if w := serve(t, "/messages/:id/requeues", h, "/messages/44/requeues"); w.Code != http.StatusNotFound {
+		t.Fatalf("GET unknown message requeues = %d %s, want 404", w.Code, w.Body)
+	}
+}
@@ -6,15 +6,19 @@ import (
 	"net/http"
 	"time"
 
+	"insider-message-sender/internal/cache"
 	"insider-message-sender/internal/config"
+	"insider-message-sender/internal/importer"
 	"insider-message-sender/internal/repository"
 	"insider-message-sender/internal/scheduler"
 
 	_ "insider-message-sender/internal/docs"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 type Server struct {
@@ -26,17 +30,39 @@ type Server struct {
 // @description Golang-based automatic message sending service
 // @host localhost:8080
 // @BasePath /
-func NewServer(cfg *config.Config, s *scheduler.Scheduler, repo *repository.MessageRepository) *Server {
+func NewServer(cfg *config.Config, s *scheduler.Scheduler, repo *repository.MessageRepository,
+	jobs *repository.ImportJobRepository, imp *importer.Importer, redisClient *cache.RedisClient) *Server {
 	r := gin.Default()
+	r.Use(otelgin.Middleware(cfg.ServiceName, otelgin.WithFilter(tracedRequest)))
 
 	// Health check endpoint (no versioning needed)
 	r.GET("/health", HealthCheck(s, repo))
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
 	v1 := r.Group("/api/v1")
 	v1.POST("/scheduler/start", StartScheduler(s))
 	v1.POST("/scheduler/stop", StopScheduler(s))
+	v1.GET("/scheduler/status", GetSchedulerStatus(s))
+	v1.GET("/scheduler/config", GetSchedulerConfig(s))
+	v1.PUT("/scheduler/config", UpdateSchedulerConfig(s))
+	v1.DELETE("/scheduler/config", ResetSchedulerConfig(s))
+	v1.POST("/messages", CreateMessage(repo))
+	v1.GET("/messages", ListMessages(repo))
+	v1.POST("/messages/batch", CreateMessagesBatch(repo))
+	v1.POST("/messages/imports", CreateImport(imp))
+	v1.GET("/messages/imports/:id", GetImport(jobs))
 	v1.GET("/messages/sent", GetSentMessages(repo))
 	v1.GET("/messages/failed", GetFailedMessages(repo))
+	v1.POST("/messages/failed/requeue", RequeueFailedMessages(repo))
+	v1.POST("/messages/:id/requeue", RequeueMessage(repo))
+	v1.GET("/messages/:id/requeues", GetMessageRequeues(repo))
+	v1.GET("/messages/:id/events", GetMessageEvents(repo))
+	v1.GET("/messages/expired", GetExpiredMessages(repo))
+	v1.GET("/messages/by-provider-id/:messageId", GetMessageByProviderID(repo, redisClient))
+	v1.GET("/messages/stats", GetMessageStats(repo))
+	v1.GET("/messages/export", ExportMessages(repo))
+	v1.GET("/messages/:id", GetMessage(repo))
+	v1.POST("/callbacks/delivery", DeliveryCallback(cfg, repo, redisClient))
 
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
@@ -49,6 +75,11 @@ func NewServer(cfg *config.Config, s *scheduler.Scheduler, repo *repository.Mess
 	return &Server{httpServer: httpServer}
 }
 
+// tracedRequest leaves probes and metric scrapes out of traces
+func tracedRequest(r *http.Request) bool {
+	return r.URL.Path != "/health" && r.URL.Path != "/metrics"
+}
+
 func (s *Server) Start() error {
 	return s.httpServer.ListenAndServe()
 }
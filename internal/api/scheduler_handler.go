@@ -1,6 +1,9 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"log"
 	"net/http"
 	"time"
 
@@ -12,6 +15,7 @@ import (
 
 // @Summary Start automatic message sending
 // @Description Starts the background scheduler that periodically sends pending messages every configured interval.
+// @Description With leader election enabled the replica starts in standby until it acquires leadership.
 // @Tags Scheduler
 // @Produce json
 // @Success 200 {object} model.SchedulerActionResponse
@@ -29,15 +33,18 @@ func StartScheduler(s *scheduler.Scheduler) gin.HandlerFunc {
 			return
 		}
 		c.JSON(http.StatusOK, model.SchedulerActionResponse{
-			Status:  "success",
-			Message: "Scheduler started successfully",
-			Time:    time.Now().Format(time.RFC3339),
+			Status:   "success",
+			Message:  "Scheduler started successfully",
+			Instance: s.InstanceID(),
+			Leader:   currentLeader(c.Request.Context(), s),
+			Time:     time.Now().Format(time.RFC3339),
 		})
 	}
 }
 
 // @Summary Stop automatic message sending
 // @Description Stops the background scheduler. No further messages will be sent until restarted.
+// @Description Responds once messages that were already being sent have recorded their outcome.
 // @Tags Scheduler
 // @Produce json
 // @Success 200 {object} model.SchedulerActionResponse
@@ -55,9 +62,181 @@ func StopScheduler(s *scheduler.Scheduler) gin.HandlerFunc {
 			return
 		}
 		c.JSON(http.StatusOK, model.SchedulerActionResponse{
-			Status:  "success",
-			Message: "Scheduler stopped successfully",
-			Time:    time.Now().Format(time.RFC3339),
+			Status:   "success",
+			Message:  "Scheduler stopped successfully",
+			Instance: s.InstanceID(),
+			Leader:   currentLeader(c.Request.Context(), s),
+			Time:     time.Now().Format(time.RFC3339),
 		})
 	}
 }
+
+// @Summary Get scheduler status
+// @Description Reports whether the scheduler runs on this replica, its role, the replica currently leading
+// @Description, the circuit breaker state of every provider and the reconciliation backlog.
+// @Tags Scheduler
+// @Produce json
+// @Success 200 {object} model.SchedulerStatusResponse
+// @Router /api/v1/scheduler/status [get]
+func GetSchedulerStatus(s *scheduler.Scheduler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		backlog, err := s.ReconciliationBacklog(c.Request.Context())
+		if err != nil {
+			log.Printf("Failed to read reconciliation backlog: %v", err)
+			backlog = -1
+		}
+
+		c.JSON(http.StatusOK, model.SchedulerStatusResponse{
+			Running:               s.IsRunning(),
+			Role:                  schedulerRole(s),
+			Instance:              s.InstanceID(),
+			Leader:                currentLeader(c.Request.Context(), s),
+			Circuits:              circuitResponses(s),
+			ReconciliationBacklog: backlog,
+			Time:                  time.Now().Format(time.RFC3339),
+		})
+	}
+}
+
+func circuitResponses(s *scheduler.Scheduler) []model.CircuitResponse {
+	circuits := s.Circuits()
+	out := make([]model.CircuitResponse, len(circuits))
+	for i, cb := range circuits {
+		out[i] = model.CircuitResponse{
+			Provider:            cb.Provider,
+			State:               cb.State,
+			ConsecutiveFailures: cb.ConsecutiveFailures,
+		}
+		if !cb.OpenedAt.IsZero() {
+			out[i].OpenedAt = &cb.OpenedAt
+			out[i].RetryAt = &cb.RetryAt
+		}
+	}
+	return out
+}
+
+// @Summary Get scheduler settings
+// @Description Returns the batch size, concurrency limit and send interval currently in effect.
+// @Tags Scheduler
+// @Produce json
+// @Success 200 {object} model.SchedulerConfigResponse
+// @Router /api/v1/scheduler/config [get]
+func GetSchedulerConfig(s *scheduler.Scheduler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, schedulerConfigResponse(s.Settings()))
+	}
+}
+
+// @Summary Update scheduler settings
+// @Description Changes batch size, concurrency limit and/or send interval at runtime. Omitted fields keep their value.
+// @Description Changes are shared with all replicas and take effect on the next tick. They are kept in Redis and
+// @Description override the environment, also across restarts, until reset with DELETE.
+// @Tags Scheduler
+// @Accept json
+// @Produce json
+// @Param config body model.UpdateSchedulerConfigRequest true "Settings to change"
+// @Success 200 {object} model.SchedulerConfigResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 422 {object} model.ValidationErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/scheduler/config [put]
+func UpdateSchedulerConfig(s *scheduler.Scheduler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req model.UpdateSchedulerConfigRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, "Invalid request body: "+err.Error())
+			return
+		}
+
+		settings := s.Settings()
+		if req.BatchSize != nil {
+			settings.BatchSize = *req.BatchSize
+		}
+		if req.MaxConcurrency != nil {
+			settings.MaxConcurrency = *req.MaxConcurrency
+		}
+		if req.SendInterval != nil {
+			interval, err := time.ParseDuration(*req.SendInterval)
+			if err != nil {
+				c.JSON(http.StatusUnprocessableEntity, model.ValidationErrorResponse{
+					Status:  "error",
+					Message: "Validation failed",
+					Errors:  []model.FieldError{{Field: "send_interval", Message: "must be a duration such as 30s or 2m"}},
+					Time:    time.Now().Format(time.RFC3339),
+				})
+				return
+			}
+			settings.SendInterval = interval
+		}
+
+		// Range checks live in Settings.Validate, which UpdateSettings applies
+		if err := s.UpdateSettings(settings); err != nil {
+			var settingsErr *scheduler.SettingsError
+			if errors.As(err, &settingsErr) {
+				c.JSON(http.StatusUnprocessableEntity, model.ValidationErrorResponse{
+					Status:  "error",
+					Message: "Validation failed",
+					Errors:  []model.FieldError{{Field: settingsErr.Field, Message: settingsErr.Message}},
+					Time:    time.Now().Format(time.RFC3339),
+				})
+				return
+			}
+			log.Printf("Failed to update scheduler settings: %v", err)
+			respondError(c, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+
+		c.JSON(http.StatusOK, schedulerConfigResponse(s.Settings()))
+	}
+}
+
+// @Summary Reset scheduler settings
+// @Description Removes the settings stored through PUT, so every replica goes back to the batch size, concurrency
+// @Description limit and send interval from its environment on the next tick.
+// @Tags Scheduler
+// @Produce json
+// @Success 200 {object} model.SchedulerConfigResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/scheduler/config [delete]
+func ResetSchedulerConfig(s *scheduler.Scheduler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := s.ResetSettings(); err != nil {
+			log.Printf("Failed to reset scheduler settings: %v", err)
+			respondError(c, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+
+		c.JSON(http.StatusOK, schedulerConfigResponse(s.Settings()))
+	}
+}
+
+func schedulerConfigResponse(st scheduler.Settings) model.SchedulerConfigResponse {
+	return model.SchedulerConfigResponse{
+		BatchSize:      st.BatchSize,
+		MaxConcurrency: st.MaxConcurrency,
+		SendInterval:   st.SendInterval.String(),
+	}
+}
+
+func schedulerRole(s *scheduler.Scheduler) string {
+	switch {
+	case !s.IsRunning():
+		return "stopped"
+	case s.IsLeader():
+		return "leader"
+	default:
+		return "standby"
+	}
+}
+
+func currentLeader(ctx context.Context, s *scheduler.Scheduler) string {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	leader, err := s.Leader(ctx)
+	if err != nil {
+		log.Printf("Failed to look up scheduler leader: %v", err)
+		return "unknown"
+	}
+	return leader
+}
@@ -2,8 +2,15 @@ package cache
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"time"
 
+	"insider-message-sender/internal/model"
+
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -16,6 +23,10 @@ func NewRedisClient(addr string) *RedisClient {
 		Addr: addr,
 		DB:   0,
 	})
+	// Commands are traced as children of the span in the context they are called with
+	if err := redisotel.InstrumentTracing(rdb); err != nil {
+		log.Printf("Failed to instrument Redis tracing: %v", err)
+	}
 	return &RedisClient{Client: rdb}
 }
 
@@ -23,6 +34,166 @@ func (r *RedisClient) Set(ctx context.Context, key, value string, ttl time.Durat
 	return r.Client.Set(ctx, key, value, ttl).Err()
 }
 
+// Get returns the value stored at key, or an empty string when the key does not exist
+func (r *RedisClient) Get(ctx context.Context, key string) (string, error) {
+	val, err := r.Client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return val, err
+}
+
+// Delete removes key; a key that does not exist is not an error
+func (r *RedisClient) Delete(ctx context.Context, key string) error {
+	return r.Client.Del(ctx, key).Err()
+}
+
+// renewLockScript extends the TTL only if the lock is still held by the caller
+var renewLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseLockScript deletes the lock only if it is still held by the caller
+var releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// AcquireLock sets key to owner with the given TTL if nobody holds it yet
+func (r *RedisClient) AcquireLock(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	return r.Client.SetNX(ctx, key, owner, ttl).Result()
+}
+
+// RenewLock extends the TTL of a lock held by owner, returning false if the lock was lost
+func (r *RedisClient) RenewLock(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	n, err := renewLockScript.Run(ctx, r.Client, []string{key}, owner, ttl.Milliseconds()).Int()
+	return n == 1, err
+}
+
+// ReleaseLock removes a lock held by owner; locks held by others are left untouched
+func (r *RedisClient) ReleaseLock(ctx context.Context, key, owner string) error {
+	return releaseLockScript.Run(ctx, r.Client, []string{key}, owner).Err()
+}
+
+// reserveTokenScript runs a token bucket stored in a hash. It always takes a token, letting the bucket go into
+// debt, and returns how many milliseconds the caller has to wait before using it.
+var reserveTokenScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / 1000) - 1
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", now)
+redis.call("PEXPIRE", KEYS[1], math.ceil((burst - tokens) * 1000 / rate) + 1000)
+if tokens >= 0 then
+	return 0
+end
+return math.ceil(-tokens * 1000 / rate)
+`)
+
+// ReserveToken takes a token from the shared bucket at key, refilled at rate tokens per second up to burst,
+// and returns how long the caller has to wait before the token may be used
+func (r *RedisClient) ReserveToken(ctx context.Context, key string, rate float64, burst int) (time.Duration, error) {
+	ms, err := reserveTokenScript.Run(ctx, r.Client, []string{key}, rate, burst).Int64()
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+const deliveryKeyPrefix = "insider:msg:delivered"
+
+// RecordDelivery remembers for ttl that a provider accepted a message, so it is never sent again even if
+// persisting the outcome in the database failed
+func (r *RedisClient) RecordDelivery(ctx context.Context, o model.SentOutcome, ttl time.Duration) error {
+	payload, err := json.Marshal(o)
+	if err != nil {
+		return err
+	}
+	return r.Client.Set(ctx, fmt.Sprintf("%s:%d", deliveryKeyPrefix, o.MessageID), payload, ttl).Err()
+}
+
+// LookupDelivery returns the recorded delivery of message id, or nil if none is recorded
+func (r *RedisClient) LookupDelivery(ctx context.Context, id int64) (*model.SentOutcome, error) {
+	payload, err := r.Get(ctx, fmt.Sprintf("%s:%d", deliveryKeyPrefix, id))
+	if err != nil || payload == "" {
+		return nil, err
+	}
+	var o model.SentOutcome
+	if err := json.Unmarshal([]byte(payload), &o); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+const sentKeyPrefix = "insider:msg:sent"
+
+// CacheSent stores a sent message under its provider messageId for ttl, pointing back to the message row
+func (r *RedisClient) CacheSent(ctx context.Context, o model.SentOutcome, ttl time.Duration) error {
+	payload, err := json.Marshal(o)
+	if err != nil {
+		return err
+	}
+	return r.Client.Set(ctx, sentKeyPrefix+":"+o.ProviderMessageID, payload, ttl).Err()
+}
+
+// LookupSent returns the sent message cached under the provider messageId, or nil if it is not cached
+func (r *RedisClient) LookupSent(ctx context.Context, providerMessageID string) (*model.SentOutcome, error) {
+	payload, err := r.Get(ctx, sentKeyPrefix+":"+providerMessageID)
+	if err != nil || payload == "" {
+		return nil, err
+	}
+	var o model.SentOutcome
+	if err := json.Unmarshal([]byte(payload), &o); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+const reconciliationQueueKey = "insider:reconcile:sent"
+
+// EnqueueReconciliation queues a send outcome that could not be persisted so it is recorded later instead of resent
+func (r *RedisClient) EnqueueReconciliation(ctx context.Context, o model.SentOutcome) error {
+	payload, err := json.Marshal(o)
+	if err != nil {
+		return err
+	}
+	return r.Client.LPush(ctx, reconciliationQueueKey, payload).Err()
+}
+
+// DequeueReconciliation takes the oldest queued send outcome, returning nil when the queue is empty
+func (r *RedisClient) DequeueReconciliation(ctx context.Context) (*model.SentOutcome, error) {
+	payload, err := r.Client.RPop(ctx, reconciliationQueueKey).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var o model.SentOutcome
+	if err := json.Unmarshal([]byte(payload), &o); err != nil {
+		return nil, fmt.Errorf("malformed reconciliation entry %q: %w", payload, err)
+	}
+	return &o, nil
+}
+
+// ReconciliationBacklog returns how many send outcomes are waiting to be persisted
+func (r *RedisClient) ReconciliationBacklog(ctx context.Context) (int64, error) {
+	return r.Client.LLen(ctx, reconciliationQueueKey).Result()
+}
+
 func (r *RedisClient) Close() error {
 	return r.Client.Close()
 }
@@ -4,8 +4,12 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
+	"insider-message-sender/internal/constants"
+
 	"github.com/joho/godotenv"
 )
 
@@ -19,6 +23,63 @@ type Config struct {
 	WebhookURL   string
 	SendInterval time.Duration
 	ServerPort   string
+
+	// Providers are the named outbound gateways messages can be routed to
+	Providers []ProviderConfig
+	// Routes pick providers per message; without rules every message goes to the first provider
+	Routes []RouteRule
+	// CircuitFailureThreshold is how many consecutive transient failures open a provider's circuit
+	CircuitFailureThreshold int
+	// CircuitOpenTimeout is how long an open circuit rejects sends before letting a probe through
+	CircuitOpenTimeout time.Duration
+	// RateLimits paces sends per provider; providers without an entry are not limited
+	RateLimits map[string]RateLimit
+	// RateLimitShared keeps the rate limit buckets in Redis so replicas share them
+	RateLimitShared bool
+	// DeliveryDedupTTL is how long Redis remembers delivered messages so they are never sent twice
+	DeliveryDedupTTL time.Duration
+	// SentCacheTTL is how long Redis keeps the provider messageId of a sent message
+	SentCacheTTL time.Duration
+	// ReconcileInterval is how often send outcomes that could not be persisted are retried
+	ReconcileInterval time.Duration
+	// CallbackToken, when set, must be sent by providers calling the delivery receipt endpoint.
+	// Unset leaves the endpoint unauthenticated, which main warns about at startup.
+	CallbackToken string
+
+	// BatchSize is how many messages are claimed per tick
+	BatchSize int
+	// MaxConcurrency caps the number of messages sent in parallel
+	MaxConcurrency int
+	// PriorityShares is the relative share of each tick's batch reserved for each priority class
+	PriorityShares map[string]int
+
+	// RetryMaxAttempts is how many delivery attempts a message gets before it is marked failed
+	RetryMaxAttempts int
+	// RetryBaseDelay is the wait after the first failed attempt, doubled for every further attempt
+	RetryBaseDelay time.Duration
+	// RetryMaxDelay caps the wait between attempts
+	RetryMaxDelay time.Duration
+	// RetryJitter randomizes retry delays by up to this fraction in either direction
+	RetryJitter float64
+
+	// InstanceID identifies this replica as the owner of claimed messages
+	InstanceID string
+	// LeaseDuration is how long a claimed message stays reserved before the reaper returns it to pending
+	LeaseDuration time.Duration
+	// LeaseReapInterval is how often expired leases are looked for
+	LeaseReapInterval time.Duration
+
+	// LeaderElection makes replicas elect a single active scheduler through Redis
+	LeaderElection bool
+	// LeaderLeaseTTL is how long leadership survives without renewal before a standby takes over
+	LeaderLeaseTTL time.Duration
+
+	// ServiceName identifies this service in traces
+	ServiceName string
+	// TracingExporter is where spans are sent: none, otlp or stdout
+	TracingExporter string
+	// TracingSampleRatio is the fraction of new traces that are recorded; incoming sampling decisions are kept
+	TracingSampleRatio float64
 }
 
 func Load() *Config {
@@ -29,6 +90,125 @@ func Load() *Config {
 		log.Fatalf("Invalid SEND_INTERVAL: %v", err)
 	}
 
+	batchSize, err := strconv.Atoi(getEnv("BATCH_SIZE", false, "2"))
+	if err != nil || batchSize < 1 {
+		log.Fatalf("Invalid BATCH_SIZE: must be a positive integer")
+	}
+
+	maxConcurrency, err := strconv.Atoi(getEnv("MAX_CONCURRENCY", false, "10"))
+	if err != nil || maxConcurrency < 1 {
+		log.Fatalf("Invalid MAX_CONCURRENCY: must be a positive integer")
+	}
+
+	priorityShares, err := parsePriorityShares(getEnv("PRIORITY_SHARES", false, "high=70,normal=25,low=5"))
+	if err != nil {
+		log.Fatalf("Invalid PRIORITY_SHARES: %v", err)
+	}
+
+	retryMaxAttempts, err := strconv.Atoi(getEnv("RETRY_MAX_ATTEMPTS", false, "3"))
+	if err != nil || retryMaxAttempts < 1 {
+		log.Fatalf("Invalid RETRY_MAX_ATTEMPTS: must be a positive integer")
+	}
+
+	retryBaseDelay, err := time.ParseDuration(getEnv("RETRY_BASE_DELAY", false, "30s"))
+	if err != nil {
+		log.Fatalf("Invalid RETRY_BASE_DELAY: %v", err)
+	}
+
+	retryMaxDelay, err := time.ParseDuration(getEnv("RETRY_MAX_DELAY", false, "30m"))
+	if err != nil || retryMaxDelay < retryBaseDelay {
+		log.Fatalf("Invalid RETRY_MAX_DELAY: must be a duration not shorter than RETRY_BASE_DELAY")
+	}
+
+	retryJitter, err := strconv.ParseFloat(getEnv("RETRY_JITTER", false, "0.2"), 64)
+	if err != nil || !(retryJitter >= 0 && retryJitter <= 1) {
+		log.Fatalf("Invalid RETRY_JITTER: must be a fraction between 0 and 1")
+	}
+
+	leaseDuration, err := time.ParseDuration(getEnv("LEASE_DURATION", false, "5m"))
+	if err != nil || leaseDuration <= 0 {
+		log.Fatalf("Invalid LEASE_DURATION: must be a positive duration")
+	}
+
+	leaseReapInterval, err := time.ParseDuration(getEnv("LEASE_REAP_INTERVAL", false, "1m"))
+	if err != nil || leaseReapInterval <= 0 {
+		log.Fatalf("Invalid LEASE_REAP_INTERVAL: must be a positive duration")
+	}
+
+	leaderElection, err := strconv.ParseBool(getEnv("LEADER_ELECTION", false, "false"))
+	if err != nil {
+		log.Fatalf("Invalid LEADER_ELECTION: %v", err)
+	}
+
+	leaderLeaseTTL, err := time.ParseDuration(getEnv("LEADER_LEASE_TTL", false, "15s"))
+	// The lease is renewed every third of its TTL, which must leave room for Redis round-trips
+	if err != nil || leaderLeaseTTL < time.Second {
+		log.Fatalf("Invalid LEADER_LEASE_TTL: must be a duration of at least 1s")
+	}
+
+	// A single provider built from SMS_PROVIDER and WEBHOOK_URL unless several are configured
+	defaultProvider := getEnv("SMS_PROVIDER", false, "webhook")
+	webhookURL := getEnv("WEBHOOK_URL", false, "")
+	providers := []ProviderConfig{{Name: defaultProvider, Type: defaultProvider, URL: webhookURL}}
+	if v := getEnv("PROVIDERS", false, ""); v != "" {
+		providers, err = parseProviders(v)
+		if err != nil {
+			log.Fatalf("Invalid PROVIDERS: %v", err)
+		}
+	}
+
+	routes, err := parseRoutes(getEnv("PROVIDER_ROUTES", false, ""), providers)
+	if err != nil {
+		log.Fatalf("Invalid PROVIDER_ROUTES: %v", err)
+	}
+
+	circuitFailureThreshold, err := strconv.Atoi(getEnv("CIRCUIT_FAILURE_THRESHOLD", false, "5"))
+	if err != nil || circuitFailureThreshold < 1 {
+		log.Fatalf("Invalid CIRCUIT_FAILURE_THRESHOLD: must be a positive integer")
+	}
+
+	circuitOpenTimeout, err := time.ParseDuration(getEnv("CIRCUIT_OPEN_TIMEOUT", false, "30s"))
+	if err != nil || circuitOpenTimeout <= 0 {
+		log.Fatalf("Invalid CIRCUIT_OPEN_TIMEOUT: must be a positive duration")
+	}
+
+	rateLimits, err := parseRateLimits(getEnv("PROVIDER_RATE_LIMITS", false, ""), providers)
+	if err != nil {
+		log.Fatalf("Invalid PROVIDER_RATE_LIMITS: %v", err)
+	}
+
+	rateLimitShared, err := strconv.ParseBool(getEnv("RATE_LIMIT_SHARED", false, "false"))
+	if err != nil {
+		log.Fatalf("Invalid RATE_LIMIT_SHARED: %v", err)
+	}
+
+	deliveryDedupTTL, err := time.ParseDuration(getEnv("DELIVERY_DEDUP_TTL", false, "72h"))
+	if err != nil || deliveryDedupTTL <= 0 {
+		log.Fatalf("Invalid DELIVERY_DEDUP_TTL: must be a positive duration")
+	}
+
+	sentCacheTTL, err := time.ParseDuration(getEnv("SENT_CACHE_TTL", false, "168h"))
+	if err != nil || sentCacheTTL <= 0 {
+		log.Fatalf("Invalid SENT_CACHE_TTL: must be a positive duration")
+	}
+
+	reconcileInterval, err := time.ParseDuration(getEnv("RECONCILE_INTERVAL", false, "30s"))
+	if err != nil || reconcileInterval <= 0 {
+		log.Fatalf("Invalid RECONCILE_INTERVAL: must be a positive duration")
+	}
+
+	tracingExporter := getEnv("TRACING_EXPORTER", false, constants.TracingExporterNone)
+	if !constants.IsValidTracingExporter(tracingExporter) {
+		log.Fatalf("Invalid TRACING_EXPORTER: must be one of %s", strings.Join(constants.TracingExporterValues(), ", "))
+	}
+
+	tracingSampleRatio, err := strconv.ParseFloat(getEnv("TRACING_SAMPLE_RATIO", false, "1"), 64)
+	if err != nil || !(tracingSampleRatio >= 0 && tracingSampleRatio <= 1) {
+		log.Fatalf("Invalid TRACING_SAMPLE_RATIO: must be a fraction between 0 and 1")
+	}
+
+	hostname, _ := os.Hostname()
+
 	return &Config{
 		DBHost:       getEnv("DB_HOST", true, ""),
 		DBPort:       getEnv("DB_PORT", false, "5432"),
@@ -36,9 +216,40 @@ func Load() *Config {
 		DBPassword:   getEnv("DB_PASSWORD", true, ""),
 		DBName:       getEnv("DB_NAME", true, ""),
 		RedisHost:    getEnv("REDIS_ADDR", true, ""),
-		WebhookURL:   getEnv("WEBHOOK_URL", true, ""),
+		WebhookURL:   webhookURL,
 		SendInterval: interval,
 		ServerPort:   getEnv("SERVER_PORT", false, "8080"),
+
+		Providers:               providers,
+		Routes:                  routes,
+		CircuitFailureThreshold: circuitFailureThreshold,
+		CircuitOpenTimeout:      circuitOpenTimeout,
+		RateLimits:              rateLimits,
+		RateLimitShared:         rateLimitShared,
+		DeliveryDedupTTL:        deliveryDedupTTL,
+		SentCacheTTL:            sentCacheTTL,
+		ReconcileInterval:       reconcileInterval,
+		CallbackToken:           getEnv("DLR_CALLBACK_TOKEN", false, ""),
+
+		BatchSize:      batchSize,
+		MaxConcurrency: maxConcurrency,
+		PriorityShares: priorityShares,
+
+		RetryMaxAttempts: retryMaxAttempts,
+		RetryBaseDelay:   retryBaseDelay,
+		RetryMaxDelay:    retryMaxDelay,
+		RetryJitter:      retryJitter,
+
+		InstanceID:        getEnv("INSTANCE_ID", false, fmt.Sprintf("%s-%d", hostname, os.Getpid())),
+		LeaseDuration:     leaseDuration,
+		LeaseReapInterval: leaseReapInterval,
+
+		LeaderElection: leaderElection,
+		LeaderLeaseTTL: leaderLeaseTTL,
+
+		ServiceName:        getEnv("OTEL_SERVICE_NAME", false, "insider-message-sender"),
+		TracingExporter:    tracingExporter,
+		TracingSampleRatio: tracingSampleRatio,
 	}
 }
 
@@ -52,3 +263,29 @@ func getEnv(key string, required bool, fallback string) string {
 	}
 	panic(fmt.Sprintf("%s is required", key))
 }
+
+// parsePriorityShares parses "high=70,normal=25,low=5"; classes that are left out get no reserved share
+func parsePriorityShares(v string) (map[string]int, error) {
+	shares := make(map[string]int)
+	total := 0
+	for _, pair := range strings.Split(v, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return nil, fmt.Errorf("expected class=share, got %q", pair)
+		}
+		name = strings.TrimSpace(name)
+		if !constants.IsValidMessagePriority(name) {
+			return nil, fmt.Errorf("unknown priority %q", name)
+		}
+		share, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || share < 0 {
+			return nil, fmt.Errorf("share of %q must be a non-negative integer", name)
+		}
+		shares[name] = share
+		total += share
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("at least one priority needs a positive share")
+	}
+	return shares, nil
+}
@@ -0,0 +1,183 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"insider-message-sender/internal/constants"
+)
+
+// ProviderConfig describes one named outbound gateway
+type ProviderConfig struct {
+	Name string
+	// Type selects the adapter, e.g. "webhook"
+	Type string
+	URL  string
+}
+
+// RouteRule sends messages matching Match to its targets. Match is a destination prefix such as "+84",
+// "priority:<class>" or "*" for any message.
+type RouteRule struct {
+	Match   string
+	Targets []RouteTarget
+}
+
+// RouteTarget is a provider a rule may send to. Traffic is split between targets with a positive weight;
+// the remaining targets are only used to fail over.
+type RouteTarget struct {
+	Provider string
+	Weight   int
+}
+
+// parseProviders parses "primary=webhook:https://a.example,backup=webhook:https://b.example"
+func parseProviders(v string) ([]ProviderConfig, error) {
+	var providers []ProviderConfig
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(v, ",") {
+		name, spec, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			return nil, fmt.Errorf("expected name=type:url, got %q", entry)
+		}
+		name = strings.TrimSpace(name)
+		typ, url, _ := strings.Cut(strings.TrimSpace(spec), ":")
+		if name == "" || typ == "" {
+			return nil, fmt.Errorf("expected name=type:url, got %q", entry)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("provider %q is defined twice", name)
+		}
+		seen[name] = true
+		providers = append(providers, ProviderConfig{Name: name, Type: typ, URL: strings.TrimSpace(url)})
+	}
+	return providers, nil
+}
+
+// parseRoutes parses "+84=viettel;priority:high=primary,backup;*=primary:80,backup:20".
+// Rules are separated by ";" and evaluated in order. A rule without weights sends to its first target
+// and fails over to the others.
+func parseRoutes(v string, providers []ProviderConfig) ([]RouteRule, error) {
+	known := make(map[string]bool, len(providers))
+	for _, p := range providers {
+		known[p.Name] = true
+	}
+
+	var rules []RouteRule
+	for _, entry := range strings.Split(v, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		match, targets, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("expected match=providers, got %q", entry)
+		}
+		match = strings.TrimSpace(match)
+		if err := validateRouteMatch(match); err != nil {
+			return nil, err
+		}
+
+		rule := RouteRule{Match: match}
+		weighted := false
+		for _, t := range strings.Split(targets, ",") {
+			name, weight, hasWeight := strings.Cut(strings.TrimSpace(t), ":")
+			name = strings.TrimSpace(name)
+			if !known[name] {
+				return nil, fmt.Errorf("rule %q refers to unknown provider %q", match, name)
+			}
+			target := RouteTarget{Provider: name}
+			if hasWeight {
+				w, err := strconv.Atoi(strings.TrimSpace(weight))
+				if err != nil || w < 0 {
+					return nil, fmt.Errorf("weight of %q in rule %q must be a non-negative integer", name, match)
+				}
+				target.Weight = w
+				weighted = true
+			}
+			rule.Targets = append(rule.Targets, target)
+		}
+		if !weighted {
+			rule.Targets[0].Weight = 1
+		}
+		if !hasPositiveWeight(rule.Targets) {
+			return nil, fmt.Errorf("rule %q needs at least one provider with a positive weight", match)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func validateRouteMatch(match string) error {
+	switch {
+	case match == "*":
+		return nil
+	case strings.HasPrefix(match, "priority:"):
+		if !constants.IsValidMessagePriority(strings.TrimPrefix(match, "priority:")) {
+			return fmt.Errorf("unknown priority in rule %q", match)
+		}
+		return nil
+	case strings.HasPrefix(match, "+") && len(match) > 1:
+		if _, err := strconv.ParseUint(match[1:], 10, 64); err != nil {
+			return fmt.Errorf("destination prefix %q must be + followed by digits", match)
+		}
+		return nil
+	default:
+		return fmt.Errorf("rule match %q must be a +prefix, priority:<class> or *", match)
+	}
+}
+
+func hasPositiveWeight(targets []RouteTarget) bool {
+	for _, t := range targets {
+		if t.Weight > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// RateLimit caps sends to a provider at Rate messages per second with bursts of up to Burst messages
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// parseRateLimits parses "primary=50,backup=10:20" where each entry is rate[:burst]; the burst defaults to the rate
+func parseRateLimits(v string, providers []ProviderConfig) (map[string]RateLimit, error) {
+	known := make(map[string]bool, len(providers))
+	for _, p := range providers {
+		known[p.Name] = true
+	}
+
+	limits := make(map[string]RateLimit)
+	for _, entry := range strings.Split(v, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, spec, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("expected provider=rate[:burst], got %q", entry)
+		}
+		name = strings.TrimSpace(name)
+		if !known[name] {
+			return nil, fmt.Errorf("unknown provider %q", name)
+		}
+
+		rateStr, burstStr, hasBurst := strings.Cut(strings.TrimSpace(spec), ":")
+		rate, err := strconv.ParseFloat(strings.TrimSpace(rateStr), 64)
+		if err != nil || rate <= 0 || math.IsNaN(rate) || math.IsInf(rate, 0) {
+			return nil, fmt.Errorf("rate of %q must be a positive number of messages per second", name)
+		}
+		limit := RateLimit{Rate: rate, Burst: max(1, int(rate))}
+		if hasBurst {
+			burst, err := strconv.Atoi(strings.TrimSpace(burstStr))
+			if err != nil || burst < 1 {
+				return nil, fmt.Errorf("burst of %q must be a positive integer", name)
+			}
+			limit.Burst = burst
+		}
+		limits[name] = limit
+	}
+	return limits, nil
+}
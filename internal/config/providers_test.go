@@ -0,0 +1,84 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+var testProviders = []ProviderConfig{{Name: "primary"}, {Name: "backup"}, {Name: "viettel"}}
+
+func TestParseRoutes(t *testing.T) {
+	rules, err := parseRoutes(" +84=viettel ; priority:high=primary,backup;*=primary:80,backup:20 ;", testProviders)
+	if err != nil {
+		t.Fatalf("parseRoutes: %v", err)
+	}
+
+	want := []RouteRule{
+		{Match: "+84", Targets: []RouteTarget{{Provider: "viettel", Weight: 1}}},
+		// Without weights the first target takes all traffic and the others only serve as failovers
+		{Match: "priority:high", Targets: []RouteTarget{{Provider: "primary", Weight: 1}, {Provider: "backup"}}},
+		{Match: "*", Targets: []RouteTarget{{Provider: "primary", Weight: 80}, {Provider: "backup", Weight: 20}}},
+	}
+	if !reflect.DeepEqual(rules, want) {
+		t.Fatalf("parseRoutes = %+v, want %+v", rules, want)
+	}
+}
+
+func TestParseRoutesRejectsInvalidRules(t *testing.T) {
+	tests := []string{
+		"primary",
+		"*=unknown",
+		"+=primary",
+		"+84a=primary",
+		"84=primary",
+		"priority:urgent=primary",
+		"*=primary:-1",
+		"*=primary:x",
+		"*=primary:0,backup:0",
+	}
+	for _, v := range tests {
+		if _, err := parseRoutes(v, testProviders); err == nil {
+			t.Errorf("parseRoutes(%q) = nil error, want an error", v)
+		}
+	}
+}
+
+func TestParseRateLimits(t *testing.T) {
+	limits, err := parseRateLimits("primary=50, backup=0.5:20", testProviders)
+	if err != nil {
+		t.Fatalf("parseRateLimits: %v", err)
+	}
+	want := map[string]RateLimit{
+		"primary": {Rate: 50, Burst: 50},
+		"backup":  {Rate: 0.5, Burst: 20},
+	}
+	if !reflect.DeepEqual(limits, want) {
+		t.Fatalf("parseRateLimits = %+v, want %+v", limits, want)
+	}
+
+	// The burst defaults to the rate, but a bucket always holds at least one token
+	limits, err = parseRateLimits("backup=0.5", testProviders)
+	if err != nil || limits["backup"].Burst != 1 {
+		t.Fatalf("parseRateLimits(backup=0.5) = %+v, %v, want a burst of 1", limits, err)
+	}
+}
+
+func TestParseRateLimitsRejectsInvalidRates(t *testing.T) {
+	tests := []string{
+		"primary",
+		"unknown=10",
+		"primary=0",
+		"primary=-5",
+		"primary=NaN",
+		"primary=Inf",
+		"primary=+Inf",
+		"primary=fast",
+		"primary=10:0",
+		"primary=10:x",
+	}
+	for _, v := range tests {
+		if _, err := parseRateLimits(v, testProviders); err == nil {
+			t.Errorf("parseRateLimits(%q) = nil error, want an error", v)
+		}
+	}
+}
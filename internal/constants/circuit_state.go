@@ -0,0 +1,20 @@
+package constants
+
+// Circuit breaker states of an outbound provider
+const (
+	// CircuitClosed lets every send through
+	CircuitClosed = "closed"
+	// CircuitOpen rejects sends until the open timeout has passed
+	CircuitOpen = "open"
+	// CircuitHalfOpen lets a single probe through to test whether the provider recovered
+	CircuitHalfOpen = "half_open"
+)
+
+// CircuitStateValues returns all circuit breaker states
+func CircuitStateValues() []string {
+	return []string{
+		CircuitClosed,
+		CircuitOpen,
+		CircuitHalfOpen,
+	}
+}
@@ -0,0 +1,27 @@
+package constants
+
+// Send error classes
+const (
+	// ErrorClassTransient failures (timeouts, throttling, provider outages) are retried
+	ErrorClassTransient = "transient"
+	// ErrorClassPermanent failures (e.g. a malformed number) will fail again and are not retried
+	ErrorClassPermanent = "permanent"
+)
+
+// ErrorClassValues returns all valid error classes
+func ErrorClassValues() []string {
+	return []string{
+		ErrorClassTransient,
+		ErrorClassPermanent,
+	}
+}
+
+// IsValidErrorClass checks if the given error class is valid
+func IsValidErrorClass(class string) bool {
+	for _, validClass := range ErrorClassValues() {
+		if class == validClass {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,7 @@
+package constants
+
+// Supported message export formats
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatNDJSON = "ndjson"
+)
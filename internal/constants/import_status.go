@@ -0,0 +1,15 @@
+package constants
+
+// Import job status constants
+const (
+	ImportStatusQueued     = "queued"
+	ImportStatusProcessing = "processing"
+	ImportStatusCompleted  = "completed"
+	ImportStatusFailed     = "failed"
+)
+
+// Supported import file formats
+const (
+	ImportFormatCSV  = "csv"
+	ImportFormatXLSX = "xlsx"
+)
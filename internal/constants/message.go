@@ -0,0 +1,6 @@
+package constants
+
+// Message content constraints
+const (
+	MaxMessageLength = 160
+)
@@ -0,0 +1,35 @@
+package constants
+
+// Message event types, one per transition recorded in a message's timeline
+const (
+	MessageEventCreated        = "created"
+	MessageEventClaimed        = "claimed"
+	MessageEventAttempt        = "attempt"
+	MessageEventSent           = "sent"
+	MessageEventRetryScheduled = "retry_scheduled"
+	MessageEventDeferred       = "deferred"
+	MessageEventReleased       = "released"
+	MessageEventFailed         = "failed"
+	MessageEventExpired        = "expired"
+	MessageEventRequeued       = "requeued"
+	MessageEventDelivered      = "delivered"
+	MessageEventUndelivered    = "undelivered"
+)
+
+// MessageEventValues returns all valid message event types
+func MessageEventValues() []string {
+	return []string{
+		MessageEventCreated,
+		MessageEventClaimed,
+		MessageEventAttempt,
+		MessageEventSent,
+		MessageEventRetryScheduled,
+		MessageEventDeferred,
+		MessageEventReleased,
+		MessageEventFailed,
+		MessageEventExpired,
+		MessageEventRequeued,
+		MessageEventDelivered,
+		MessageEventUndelivered,
+	}
+}
@@ -0,0 +1,27 @@
+package constants
+
+// Message priority constants, from most to least urgent
+const (
+	MessagePriorityHigh   = "high"
+	MessagePriorityNormal = "normal"
+	MessagePriorityLow    = "low"
+)
+
+// MessagePriorityValues returns all valid message priorities, most urgent first
+func MessagePriorityValues() []string {
+	return []string{
+		MessagePriorityHigh,
+		MessagePriorityNormal,
+		MessagePriorityLow,
+	}
+}
+
+// IsValidMessagePriority checks if the given priority is valid
+func IsValidMessagePriority(priority string) bool {
+	for _, validPriority := range MessagePriorityValues() {
+		if priority == validPriority {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package constants
+
+// Message list sort fields; a leading "-" sorts in descending order
+const (
+	MessageSortID        = "id"
+	MessageSortCreatedAt = "created_at"
+	MessageSortSendAt    = "send_at"
+	MessageSortSentAt    = "sent_at"
+	MessageSortFailedAt  = "failed_at"
+	MessageSortExpiresAt = "expires_at"
+)
+
+// MessageSortValues returns all fields messages can be sorted by
+func MessageSortValues() []string {
+	return []string{
+		MessageSortID,
+		MessageSortCreatedAt,
+		MessageSortSendAt,
+		MessageSortSentAt,
+		MessageSortFailedAt,
+		MessageSortExpiresAt,
+	}
+}
+
+// IsValidMessageSort checks if messages can be sorted by the given field
+func IsValidMessageSort(field string) bool {
+	for _, validField := range MessageSortValues() {
+		if field == validField {
+			return true
+		}
+	}
+	return false
+}
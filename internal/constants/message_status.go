@@ -2,20 +2,43 @@ package constants
 
 // Message status constants
 const (
-	MessageStatusPending = "pending"
-	MessageStatusSent    = "sent"
-	MessageStatusFailed  = "failed"
+	MessageStatusPending    = "pending"
+	MessageStatusInProgress = "in_progress"
+	MessageStatusSent       = "sent"
+	MessageStatusFailed     = "failed"
+	MessageStatusExpired    = "expired"
+	// MessageStatusDelivered and MessageStatusUndelivered refine sent once the provider reports the handset outcome
+	MessageStatusDelivered   = "delivered"
+	MessageStatusUndelivered = "undelivered"
 )
 
 // MessageStatusValues returns all valid message status values
 func MessageStatusValues() []string {
 	return []string{
 		MessageStatusPending,
+		MessageStatusInProgress,
 		MessageStatusSent,
 		MessageStatusFailed,
+		MessageStatusExpired,
+		MessageStatusDelivered,
+		MessageStatusUndelivered,
 	}
 }
 
+// SentStatusValues returns the statuses of messages a provider accepted
+func SentStatusValues() []string {
+	return []string{
+		MessageStatusSent,
+		MessageStatusDelivered,
+		MessageStatusUndelivered,
+	}
+}
+
+// IsValidDeliveryStatus checks if the given status can be reported by a delivery receipt
+func IsValidDeliveryStatus(status string) bool {
+	return status == MessageStatusDelivered || status == MessageStatusUndelivered
+}
+
 // IsValidMessageStatus checks if the given status is valid
 func IsValidMessageStatus(status string) bool {
 	for _, validStatus := range MessageStatusValues() {
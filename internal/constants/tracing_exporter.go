@@ -0,0 +1,27 @@
+package constants
+
+// Trace exporters; none keeps propagating trace context without recording spans
+const (
+	TracingExporterNone   = "none"
+	TracingExporterOTLP   = "otlp"
+	TracingExporterStdout = "stdout"
+)
+
+// TracingExporterValues returns all supported trace exporters
+func TracingExporterValues() []string {
+	return []string{
+		TracingExporterNone,
+		TracingExporterOTLP,
+		TracingExporterStdout,
+	}
+}
+
+// IsValidTracingExporter checks if the trace exporter is supported
+func IsValidTracingExporter(exporter string) bool {
+	for _, validExporter := range TracingExporterValues() {
+		if exporter == validExporter {
+			return true
+		}
+	}
+	return false
+}
@@ -15,63 +15,163 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/v1/messages/failed": {
-            "get": {
+        "/api/v1/callbacks/delivery": {
+            "post": {
+                "description": "Providers report the handset outcome of a sent message by the messageId they returned on send.\nThe message moves from sent to delivered or undelivered with the report's timestamp and reason.\nA report older than the one already recorded is acknowledged but not applied.\nWhen DLR_CALLBACK_TOKEN is configured the X-Callback-Token header must match it.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Messages"
+                    "Callbacks"
                 ],
-                "summary": "Get list of failed messages (with pagination)",
+                "summary": "Receive a delivery receipt",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "default": 10,
-                        "description": "Number of messages to return",
-                        "name": "limit",
-                        "in": "query"
+                        "description": "Delivery receipt",
+                        "name": "report",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.DeliveryReportRequest"
+                        }
                     },
                     {
-                        "type": "integer",
-                        "default": 0,
-                        "description": "Number of messages to skip",
-                        "name": "offset",
-                        "in": "query"
+                        "type": "string",
+                        "description": "Shared secret configured as DLR_CALLBACK_TOKEN",
+                        "name": "X-Callback-Token",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.SentMessagesResponse"
+                            "$ref": "#/definitions/model.DeliveryReportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/api/v1/messages/sent": {
+        "/api/v1/messages": {
             "get": {
+                "description": "Lists messages matching every given filter with keyset pagination: pass next_cursor from a response\nas cursor to fetch the following page. A cursor is only valid with the sort it was issued for.\nSorting by sent_at, failed_at or expires_at leaves out messages where that field is not set.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Messages"
                 ],
-                "summary": "Get list of sent messages (with pagination)",
+                "summary": "Search messages",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "default": 10,
-                        "description": "Number of messages to return",
-                        "name": "limit",
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Statuses to include, repeated or comma-separated",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Exact phone number",
+                        "name": "phone_number",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Substring of the content, ignoring case",
+                        "name": "content",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created at or after (RFC 3339)",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created before (RFC 3339)",
+                        "name": "created_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sent at or after (RFC 3339)",
+                        "name": "sent_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sent before (RFC 3339)",
+                        "name": "sent_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Provider of the last delivery attempt",
+                        "name": "provider",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "-created_at",
+                        "description": "id, created_at, send_at, sent_at, failed_at or expires_at; prefix with - for descending order",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
                         "in": "query"
                     },
                     {
                         "type": "integer",
-                        "default": 0,
-                        "description": "Number of messages to skip",
-                        "name": "offset",
+                        "default": 10,
+                        "description": "Number of messages to return, at most 1000",
+                        "name": "limit",
                         "in": "query"
                     }
                 ],
@@ -79,27 +179,87 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.SentMessagesResponse"
+                            "$ref": "#/definitions/model.MessagesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
                         }
                     }
                 }
-            }
-        },
-        "/api/v1/scheduler/start": {
+            },
             "post": {
-                "description": "Starts the background scheduler that periodically sends pending messages every configured interval.",
+                "description": "Validates and stores a message with pending status so the scheduler picks it up on a later tick.\nRepeating a request with the same Idempotency-Key returns the stored message with status 200\nand the Idempotent-Replayed header instead of creating a duplicate.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Scheduler"
+                    "Messages"
+                ],
+                "summary": "Create a new message",
+                "parameters": [
+                    {
+                        "description": "Message to send",
+                        "name": "message",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.CreateMessageRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client key that makes retries of this request safe, at most 255 characters",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
                 ],
-                "summary": "Start automatic message sending",
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Replayed: a message with this Idempotency-Key already exists",
                         "schema": {
-                            "$ref": "#/definitions/model.SchedulerActionResponse"
+                            "$ref": "#/definitions/model.CreateMessageResponse"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.CreateMessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
                         }
                     },
                     "500": {
@@ -111,25 +271,55 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/scheduler/stop": {
+        "/api/v1/messages/batch": {
             "post": {
-                "description": "Stops the background scheduler. No further messages will be sent until restarted.",
+                "description": "Accepts a JSON array or NDJSON stream (Content-Type: application/x-ndjson) of messages.\nValid items are stored as pending in chunks; invalid items are reported by index without failing the batch.\nWith an Idempotency-Key header, retrying the same batch returns the stored items marked as replayed\ninstead of creating duplicates; each item is keyed by the header value and its index.",
+                "consumes": [
+                    "application/json",
+                    "application/x-ndjson"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Scheduler"
+                    "Messages"
+                ],
+                "summary": "Create messages in bulk",
+                "parameters": [
+                    {
+                        "description": "Messages to send",
+                        "name": "messages",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.CreateMessageRequest"
+                            }
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client key that makes retries of this batch safe, at most 255 characters",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
                 ],
-                "summary": "Stop automatic message sending",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.SchedulerActionResponse"
+                            "$ref": "#/definitions/model.BatchCreateMessagesResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
                         "schema": {
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
@@ -137,103 +327,1623 @@ const docTemplate = `{
                 }
             }
         },
-        "/health": {
+        "/api/v1/messages/by-provider-id/{messageId}": {
             "get": {
-                "description": "Check the health status of the service including database connectivity and scheduler status",
+                "description": "Returns the full record of the message the provider accepted under messageId, for example to match a\ndelivery report. The messageId is resolved through the Redis entry written on send and through the\ndatabase once that entry expired. If several providers issued the same messageId, pass provider to\npick one; otherwise the most recent message wins.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Health"
+                    "Messages"
+                ],
+                "summary": "Look a message up by provider messageId",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Message id returned by the provider",
+                        "name": "messageId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Provider that issued the messageId",
+                        "name": "provider",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Health check endpoint",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.HealthResponse"
+                            "$ref": "#/definitions/model.MessageDetailResponse"
                         }
                     },
-                    "503": {
-                        "description": "Service Unavailable",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/model.HealthResponse"
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "model.ErrorResponse": {
-            "type": "object",
-            "properties": {
-                "message": {
-                    "type": "string",
-                    "example": "Internal server error"
-                },
-                "status": {
-                    "type": "string",
-                    "example": "error"
-                },
-                "time": {
-                    "type": "string",
-                    "example": "2025-10-19T09:00:00Z"
-                }
-            }
         },
-        "model.HealthResponse": {
-            "type": "object",
-            "properties": {
-                "services": {
-                    "type": "object",
-                    "additionalProperties": {
-                        "type": "string"
+        "/api/v1/messages/expired": {
+            "get": {
+                "description": "Messages that passed their expires_at before they could be sent, most recently expired first.\nAccepts the filters of GET /api/v1/messages except status. Pages can be fetched by cursor or by offset;\npagination.offset and pagination.total are always reported.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Get list of expired messages (with pagination)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Number of messages to return",
+                        "name": "limit",
+                        "in": "query"
                     },
-                    "example": {
-                        "database": "healthy",
-                        "redis": "healthy",
-                        "scheduler": "running"
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of messages to skip; cannot be combined with cursor",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "-expires_at",
+                        "description": "Sort field, prefix with - for descending order",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.MessagesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
                     }
-                },
-                "status": {
-                    "type": "string",
-                    "example": "healthy"
-                },
-                "timestamp": {
-                    "type": "string",
-                    "example": "2025-10-19T09:00:00Z"
                 }
             }
         },
-        "model.Pagination": {
-            "type": "object",
-            "properties": {
-                "count": {
-                    "type": "integer",
-                    "example": 2
-                },
-                "has_more": {
-                    "type": "boolean",
-                    "example": true
-                },
-                "limit": {
+        "/api/v1/messages/export": {
+            "get": {
+                "description": "Streams every message matching the filters of GET /api/v1/messages as CSV or NDJSON with chunked\ntransfer encoding. Rows are written as they are read from the database, so exports of any size use\nconstant memory. Sorted by id unless sort is given; cursor and limit do not apply.\nIf the export fails midway the response ends early, so check that the row count is as expected.",
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Export messages",
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "ndjson"
+                        ],
+                        "type": "string",
+                        "default": "csv",
+                        "description": "Export format",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Statuses to include, repeated or comma-separated",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Exact phone number",
+                        "name": "phone_number",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Substring of the content, ignoring case",
+                        "name": "content",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created at or after (RFC 3339)",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created before (RFC 3339)",
+                        "name": "created_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sent at or after (RFC 3339)",
+                        "name": "sent_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sent before (RFC 3339)",
+                        "name": "sent_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Provider of the last delivery attempt",
+                        "name": "provider",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "id",
+                        "description": "id, created_at, send_at, sent_at, failed_at or expires_at; prefix with - for descending order",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "CSV or NDJSON stream",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/messages/failed": {
+            "get": {
+                "description": "Failed messages, most recently failed first. Accepts the filters of GET /api/v1/messages except status.\nPages can be fetched by cursor or by offset; pagination.offset and pagination.total are always reported.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Get list of failed messages (with pagination)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Number of messages to return",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of messages to skip; cannot be combined with cursor",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "-failed_at",
+                        "description": "Sort field, prefix with - for descending order",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.MessagesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/messages/failed/requeue": {
+            "post": {
+                "description": "Sends failed messages back to pending with a clean attempt state, selected either by a list of ids\nor by a filter on failure time and error class. Filter requests requeue at most ` + "`" + `limit` + "`" + ` messages\n(1000 by default); repeat the request until requeued_count is 0 to drain a larger set.\nEvery requeue is recorded in the audit trail. Ids that are not failed are skipped.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Requeue failed messages",
+                "parameters": [
+                    {
+                        "description": "Messages to requeue",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.RequeueMessagesRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.RequeueResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/messages/imports": {
+            "post": {
+                "description": "Uploads a spreadsheet and imports its rows as pending messages in the background.\nPoll the returned job for progress and rejected rows.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imports"
+                ],
+                "summary": "Import messages from a CSV or XLSX file",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "CSV or XLSX file",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "csv",
+                            "xlsx"
+                        ],
+                        "type": "string",
+                        "description": "File format, inferred from the file extension when omitted",
+                        "name": "format",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "default": "phone_number",
+                        "description": "Header name or 1-based column number of the phone number",
+                        "name": "phone_column",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "default": "content",
+                        "description": "Header name or 1-based column number of the content",
+                        "name": "content_column",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": true,
+                        "description": "Whether the first row is a header row",
+                        "name": "has_header",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "XLSX sheet name, defaults to the first sheet",
+                        "name": "sheet",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Validate rows without inserting them",
+                        "name": "dry_run",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/model.ImportJobResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/messages/imports/{id}": {
+            "get": {
+                "description": "Returns progress counters and rejected rows (up to the first 1000) of an import job.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imports"
+                ],
+                "summary": "Get import job progress",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Import job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.ImportJobResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/messages/sent": {
+            "get": {
+                "description": "Messages a provider accepted, including those with a delivery report, most recently sent first.\nAccepts the filters of GET /api/v1/messages except status. Pages can be fetched by cursor or by offset;\npagination.offset and pagination.total are always reported.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Get list of sent messages (with pagination)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Number of messages to return",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of messages to skip; cannot be combined with cursor",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "-sent_at",
+                        "description": "Sort field, prefix with - for descending order",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.MessagesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/messages/stats": {
+            "get": {
+                "description": "Returns the number of messages in every status.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Get message counters",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.MessageStatsResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/messages/{id}": {
+            "get": {
+                "description": "Returns the full record of a message, including its status, send timestamp and delivery details.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Get a message",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Message ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.MessageDetailResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/messages/{id}/events": {
+            "get": {
+                "description": "Returns every recorded transition of a message, oldest first: created, claimed, each delivery attempt\nwith the provider's HTTP status and latency, retries, sent, failed, requeued and delivery reports.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Get the timeline of a message",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Message ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.MessageEventsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/messages/{id}/requeue": {
+            "post": {
+                "description": "Sends a single failed message back to pending with a clean attempt state and records it in the audit trail.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Requeue a failed message",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Message ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Audit details",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/model.RequeueMessageRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.RequeueResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/messages/{id}/requeues": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Get the requeue audit trail of a message",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Message ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.RequeueHistoryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/scheduler/config": {
+            "get": {
+                "description": "Returns the batch size, concurrency limit and send interval currently in effect.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Scheduler"
+                ],
+                "summary": "Get scheduler settings",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.SchedulerConfigResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Changes batch size, concurrency limit and/or send interval at runtime. Omitted fields keep their value.\nChanges are shared with all replicas and take effect on the next tick. They are kept in Redis and\noverride the environment, also across restarts, until reset with DELETE.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Scheduler"
+                ],
+                "summary": "Update scheduler settings",
+                "parameters": [
+                    {
+                        "description": "Settings to change",
+                        "name": "config",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.UpdateSchedulerConfigRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.SchedulerConfigResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Removes the settings stored through PUT, so every replica goes back to the batch size, concurrency\nlimit and send interval from its environment on the next tick.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Scheduler"
+                ],
+                "summary": "Reset scheduler settings",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.SchedulerConfigResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/scheduler/start": {
+            "post": {
+                "description": "Starts the background scheduler that periodically sends pending messages every configured interval.\nWith leader election enabled the replica starts in standby until it acquires leadership.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Scheduler"
+                ],
+                "summary": "Start automatic message sending",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.SchedulerActionResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/scheduler/status": {
+            "get": {
+                "description": "Reports whether the scheduler runs on this replica, its role, the replica currently leading",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Scheduler"
+                ],
+                "summary": "Get scheduler status",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.SchedulerStatusResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/scheduler/stop": {
+            "post": {
+                "description": "Stops the background scheduler. No further messages will be sent until restarted.\nResponds once messages that were already being sent have recorded their outcome.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Scheduler"
+                ],
+                "summary": "Stop automatic message sending",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.SchedulerActionResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Check the health status of the service including database connectivity, scheduler status\nand the circuit breaker state of every provider",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Health check endpoint",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.HealthResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/model.HealthResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "model.BatchAcceptedItem": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "index": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "replayed": {
+                    "description": "Replayed is set when the item's idempotency key matched an already stored message",
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "model.BatchCreateMessagesResponse": {
+            "type": "object",
+            "properties": {
+                "accepted": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.BatchAcceptedItem"
+                    }
+                },
+                "accepted_count": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "rejected": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.BatchRejectedItem"
+                    }
+                },
+                "rejected_count": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "model.BatchRejectedItem": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.FieldError"
+                    }
+                },
+                "index": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "Validation failed"
+                }
+            }
+        },
+        "model.CircuitResponse": {
+            "type": "object",
+            "properties": {
+                "consecutive_failures": {
+                    "type": "integer",
+                    "example": 5
+                },
+                "opened_at": {
+                    "type": "string",
+                    "example": "2025-10-19T08:09:30Z"
+                },
+                "provider": {
+                    "type": "string",
+                    "example": "primary"
+                },
+                "retry_at": {
+                    "type": "string",
+                    "example": "2025-10-19T08:10:00Z"
+                },
+                "state": {
+                    "type": "string",
+                    "enum": [
+                        "closed",
+                        "open",
+                        "half_open"
+                    ],
+                    "example": "open"
+                }
+            }
+        },
+        "model.CreateMessageRequest": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string",
+                    "example": "Hello from Insider!"
+                },
+                "expires_at": {
+                    "description": "ExpiresAt drops the message instead of sending it once this time has passed",
+                    "type": "string",
+                    "example": "2025-10-20T09:05:00Z"
+                },
+                "phone_number": {
+                    "type": "string",
+                    "example": "+84901234567"
+                },
+                "priority": {
+                    "description": "Priority defaults to normal; high priority messages are claimed first",
+                    "type": "string",
+                    "enum": [
+                        "high",
+                        "normal",
+                        "low"
+                    ],
+                    "example": "normal"
+                },
+                "send_at": {
+                    "description": "SendAt schedules the message for later; it is sent as soon as possible when omitted",
+                    "type": "string",
+                    "example": "2025-10-20T09:00:00Z"
+                }
+            }
+        },
+        "model.CreateMessageResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/model.MessageResponseData"
+                }
+            }
+        },
+        "model.DeliveryReportRequest": {
+            "type": "object",
+            "properties": {
+                "messageId": {
+                    "type": "string",
+                    "example": "67f2f8a8-ea58-4ed0-a6f9-ff217df4d849"
+                },
+                "provider": {
+                    "description": "Provider narrows the lookup when several providers may have issued the messageId",
+                    "type": "string",
+                    "example": "primary"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "DELIVRD"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "delivered",
+                        "undelivered"
+                    ],
+                    "example": "delivered"
+                },
+                "timestamp": {
+                    "description": "Timestamp is when the provider observed the outcome; the time of receipt is used when omitted",
+                    "type": "string",
+                    "example": "2025-10-19T07:41:52Z"
+                }
+            }
+        },
+        "model.DeliveryReportResponse": {
+            "type": "object",
+            "properties": {
+                "applied": {
+                    "description": "Applied is false when the report was older than the one already recorded",
+                    "type": "boolean",
+                    "example": true
+                },
+                "delivery_status": {
+                    "description": "DeliveryStatus is the message's status after the report",
+                    "type": "string",
+                    "example": "delivered"
+                },
+                "id": {
+                    "description": "ID is our id of the reported message",
+                    "type": "integer",
+                    "example": 42
+                },
+                "status": {
+                    "type": "string",
+                    "example": "success"
+                },
+                "time": {
+                    "type": "string",
+                    "example": "2025-10-19T07:41:52Z"
+                }
+            }
+        },
+        "model.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Internal server error"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "error"
+                },
+                "time": {
+                    "type": "string",
+                    "example": "2025-10-19T09:00:00Z"
+                }
+            }
+        },
+        "model.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "example": "phone_number"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "must be in E.164 format, e.g. +84901234567"
+                }
+            }
+        },
+        "model.HealthResponse": {
+            "type": "object",
+            "properties": {
+                "services": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    },
+                    "example": {
+                        "circuit": "primary:closed",
+                        "database": "healthy",
+                        "instance": "app-1-7",
+                        "leader": "app-1-7",
+                        "redis": "healthy",
+                        "scheduler": "running"
+                    }
+                },
+                "status": {
+                    "type": "string",
+                    "example": "healthy"
+                },
+                "timestamp": {
+                    "type": "string",
+                    "example": "2025-10-19T09:00:00Z"
+                }
+            }
+        },
+        "model.ImportJob": {
+            "type": "object",
+            "properties": {
+                "accepted_rows": {
+                    "type": "integer",
+                    "example": 498
+                },
+                "completed_at": {
+                    "type": "string",
+                    "example": "2025-10-19T07:42:10Z"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-10-19T07:41:45Z"
+                },
+                "dry_run": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "error": {
+                    "type": "string",
+                    "example": ""
+                },
+                "filename": {
+                    "type": "string",
+                    "example": "campaign.csv"
+                },
+                "format": {
+                    "type": "string",
+                    "example": "csv"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "processed_rows": {
+                    "type": "integer",
+                    "example": 500
+                },
+                "rejected_rows": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "rejections": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ImportRejection"
+                    }
+                },
+                "status": {
+                    "type": "string",
+                    "example": "processing"
+                },
+                "total_rows": {
+                    "type": "integer",
+                    "example": 1000
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2025-10-19T07:41:50Z"
+                }
+            }
+        },
+        "model.ImportJobResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/model.ImportJob"
+                }
+            }
+        },
+        "model.ImportRejection": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.FieldError"
+                    }
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "Validation failed"
+                },
+                "row": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "model.MessageDetailResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/model.SentMessageResponseData"
+                }
+            }
+        },
+        "model.MessageEvent": {
+            "type": "object",
+            "properties": {
+                "attempt": {
+                    "description": "Attempt is the delivery attempt the event belongs to",
+                    "type": "integer",
+                    "example": 1
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-10-19T07:41:45Z"
+                },
+                "detail": {
+                    "type": "string",
+                    "example": "webhook responded 503 Service Unavailable"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 7
+                },
+                "latency_ms": {
+                    "description": "LatencyMs is how long the provider took to answer an attempt",
+                    "type": "integer",
+                    "example": 184
+                },
+                "message_id": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "provider": {
+                    "type": "string",
+                    "example": "primary"
+                },
+                "status_code": {
+                    "type": "integer",
+                    "example": 503
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "created",
+                        "claimed",
+                        "attempt",
+                        "sent",
+                        "retry_scheduled",
+                        "deferred",
+                        "released",
+                        "failed",
+                        "expired",
+                        "requeued",
+                        "delivered",
+                        "undelivered"
+                    ],
+                    "example": "attempt"
+                }
+            }
+        },
+        "model.MessageEventsResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.MessageEvent"
+                    }
+                }
+            }
+        },
+        "model.MessageResponseData": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string",
+                    "example": "Hello from Insider!"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2025-10-19T07:45:00Z"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "idempotency_key": {
+                    "type": "string",
+                    "example": "order-1234-otp"
+                },
+                "phone_number": {
+                    "type": "string",
+                    "example": "+84901234567"
+                },
+                "priority": {
+                    "type": "string",
+                    "example": "normal"
+                },
+                "send_at": {
+                    "type": "string",
+                    "example": "2025-10-19T07:40:00Z"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "pending"
+                }
+            }
+        },
+        "model.MessageStatsResponse": {
+            "type": "object",
+            "properties": {
+                "counts": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    },
+                    "example": {
+                        "expired": 2,
+                        "failed": 1,
+                        "in_progress": 0,
+                        "pending": 3,
+                        "sent": 10
+                    }
+                },
+                "time": {
+                    "type": "string",
+                    "example": "2025-10-19T09:00:00Z"
+                }
+            }
+        },
+        "model.MessagesResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.SentMessageResponseData"
+                    }
+                },
+                "pagination": {
+                    "$ref": "#/definitions/model.Pagination"
+                }
+            }
+        },
+        "model.Pagination": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "has_more": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "limit": {
                     "type": "integer",
                     "example": 10
                 },
+                "next_cursor": {
+                    "description": "NextCursor fetches the following page when passed as cursor; it is empty on the last page",
+                    "type": "string",
+                    "example": "eyJzIjoiLWNyZWF0ZWRfYXQiLCJ2IjoiMjAyNS0xMC0xOVQwNzozOToxMloiLCJpZCI6NDJ9"
+                },
                 "offset": {
+                    "description": "Offset and Total are only set by the sent, failed and expired listings",
                     "type": "integer",
                     "example": 0
                 },
                 "total": {
                     "type": "integer",
-                    "example": 5
+                    "example": 125
+                }
+            }
+        },
+        "model.Requeue": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "message_id": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "previous_attempt_count": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "previous_error": {
+                    "type": "string",
+                    "example": "webhook responded 503 Service Unavailable"
+                },
+                "previous_error_class": {
+                    "type": "string",
+                    "example": "transient"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "Provider outage resolved"
+                },
+                "requested_by": {
+                    "type": "string",
+                    "example": "ops@insider.com"
+                },
+                "requeued_at": {
+                    "type": "string",
+                    "example": "2025-10-19T09:00:00Z"
+                }
+            }
+        },
+        "model.RequeueHistoryResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Requeue"
+                    }
+                }
+            }
+        },
+        "model.RequeueMessageRequest": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "example": "Customer confirmed number"
+                },
+                "requested_by": {
+                    "type": "string",
+                    "example": "ops@insider.com"
+                }
+            }
+        },
+        "model.RequeueMessagesRequest": {
+            "type": "object",
+            "properties": {
+                "error_class": {
+                    "type": "string",
+                    "enum": [
+                        "transient",
+                        "permanent"
+                    ],
+                    "example": "transient"
+                },
+                "failed_from": {
+                    "type": "string",
+                    "example": "2025-10-19T00:00:00Z"
+                },
+                "failed_to": {
+                    "type": "string",
+                    "example": "2025-10-20T00:00:00Z"
+                },
+                "ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        1,
+                        2,
+                        3
+                    ]
+                },
+                "limit": {
+                    "description": "Limit caps how many messages one filter request requeues",
+                    "type": "integer",
+                    "example": 1000
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "Provider outage resolved"
+                },
+                "requested_by": {
+                    "type": "string",
+                    "example": "ops@insider.com"
+                }
+            }
+        },
+        "model.RequeueResponse": {
+            "type": "object",
+            "properties": {
+                "ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        1,
+                        2
+                    ]
+                },
+                "requeued_count": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "time": {
+                    "type": "string",
+                    "example": "2025-10-19T09:00:00Z"
                 }
             }
         },
         "model.SchedulerActionResponse": {
             "type": "object",
             "properties": {
+                "instance": {
+                    "type": "string",
+                    "example": "app-1-7"
+                },
+                "leader": {
+                    "type": "string",
+                    "example": "app-1-7"
+                },
                 "message": {
                     "type": "string",
                     "example": "Scheduler started successfully"
@@ -248,21 +1958,147 @@ const docTemplate = `{
                 }
             }
         },
+        "model.SchedulerConfigResponse": {
+            "type": "object",
+            "properties": {
+                "batch_size": {
+                    "type": "integer",
+                    "example": 50
+                },
+                "max_concurrency": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "send_interval": {
+                    "type": "string",
+                    "example": "30s"
+                }
+            }
+        },
+        "model.SchedulerStatusResponse": {
+            "type": "object",
+            "properties": {
+                "circuits": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.CircuitResponse"
+                    }
+                },
+                "instance": {
+                    "type": "string",
+                    "example": "app-1-7"
+                },
+                "leader": {
+                    "type": "string",
+                    "example": "app-1-7"
+                },
+                "reconciliation_backlog": {
+                    "description": "ReconciliationBacklog counts accepted sends waiting to be recorded in the database, -1 if unknown",
+                    "type": "integer",
+                    "example": 0
+                },
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "leader",
+                        "standby",
+                        "stopped"
+                    ],
+                    "example": "leader"
+                },
+                "running": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "time": {
+                    "type": "string",
+                    "example": "2025-10-19T08:10:00Z"
+                }
+            }
+        },
         "model.SentMessageResponseData": {
             "type": "object",
             "properties": {
+                "attempt_count": {
+                    "type": "integer",
+                    "example": 1
+                },
                 "content": {
                     "type": "string",
                     "example": "Hello from Insider!"
                 },
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-10-19T07:39:12Z"
+                },
+                "delivery_reason": {
+                    "type": "string",
+                    "example": "DELIVRD"
+                },
+                "delivery_reported_at": {
+                    "type": "string",
+                    "example": "2025-10-19T07:41:52Z"
+                },
+                "error_class": {
+                    "type": "string",
+                    "enum": [
+                        "transient",
+                        "permanent"
+                    ],
+                    "example": "permanent"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2025-10-19T07:45:00Z"
+                },
+                "failed_at": {
+                    "type": "string",
+                    "example": "2025-10-19T07:43:10Z"
+                },
                 "id": {
                     "type": "integer",
                     "example": 1
                 },
+                "idempotency_key": {
+                    "type": "string",
+                    "example": "order-1234-otp"
+                },
+                "last_error": {
+                    "type": "string",
+                    "example": "webhook responded 503 Service Unavailable"
+                },
+                "last_status_code": {
+                    "type": "integer",
+                    "example": 202
+                },
+                "next_attempt_at": {
+                    "type": "string",
+                    "example": "2025-10-19T07:42:15Z"
+                },
                 "phone_number": {
                     "type": "string",
                     "example": "+84901234567"
                 },
+                "priority": {
+                    "type": "string",
+                    "example": "normal"
+                },
+                "provider": {
+                    "type": "string",
+                    "example": "primary"
+                },
+                "provider_error_code": {
+                    "type": "string",
+                    "example": "21211"
+                },
+                "provider_message_id": {
+                    "type": "string",
+                    "example": "67f2f8a8-ea58-4ed0-a6f9-ff217df4d849"
+                },
+                "send_at": {
+                    "type": "string",
+                    "example": "2025-10-19T07:40:00Z"
+                },
                 "sent_at": {
                     "type": "string",
                     "example": "2025-10-19T07:41:45Z"
@@ -273,17 +2109,43 @@ const docTemplate = `{
                 }
             }
         },
-        "model.SentMessagesResponse": {
+        "model.UpdateSchedulerConfigRequest": {
             "type": "object",
             "properties": {
-                "data": {
+                "batch_size": {
+                    "type": "integer",
+                    "example": 50
+                },
+                "max_concurrency": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "send_interval": {
+                    "type": "string",
+                    "example": "30s"
+                }
+            }
+        },
+        "model.ValidationErrorResponse": {
+            "type": "object",
+            "properties": {
+                "errors": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/model.SentMessageResponseData"
+                        "$ref": "#/definitions/model.FieldError"
                     }
                 },
-                "pagination": {
-                    "$ref": "#/definitions/model.Pagination"
+                "message": {
+                    "type": "string",
+                    "example": "Validation failed"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "error"
+                },
+                "time": {
+                    "type": "string",
+                    "example": "2025-10-19T09:00:00Z"
                 }
             }
         }
@@ -0,0 +1,307 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"insider-message-sender/internal/model"
+	"insider-message-sender/internal/repository"
+	"insider-message-sender/internal/validator"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("insider-message-sender/internal/importer")
+
+const (
+	chunkSize           = 500
+	maxStoredRejections = 1000
+)
+
+// ColumnMapping tells the importer where to find message fields in the uploaded file.
+// Columns are matched by header name (case-insensitive) or by 1-based column number.
+type ColumnMapping struct {
+	PhoneColumn   string
+	ContentColumn string
+	HasHeader     bool
+	Sheet         string
+}
+
+// Importer processes uploaded spreadsheets in the background and records progress on the import job
+type Importer struct {
+	repo   *repository.MessageRepository
+	jobs   *repository.ImportJobRepository
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewImporter(repo *repository.MessageRepository, jobs *repository.ImportJobRepository) *Importer {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Importer{
+		repo:   repo,
+		jobs:   jobs,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Start records a new import job and processes the file at path asynchronously.
+// The importer takes ownership of the file and removes it once the job finishes.
+// The job is traced on its own, linked to the trace in ctx, since it outlives the request that started it.
+func (i *Importer) Start(ctx context.Context, job *model.ImportJob, path string, mapping ColumnMapping) error {
+	if err := i.jobs.Create(ctx, job); err != nil {
+		_ = os.Remove(path)
+		return err
+	}
+
+	link := trace.LinkFromContext(ctx)
+	i.wg.Add(1)
+	go func(jobID int64) {
+		defer i.wg.Done()
+		defer os.Remove(path) //nolint:errcheck
+
+		// Shutdown interrupts the import loop through i.ctx, but the final job state must still be written
+		ctx, span := tracer.Start(context.Background(), "importer.import", trace.WithLinks(link), trace.WithAttributes(
+			attribute.Int64("import.job_id", jobID),
+			attribute.String("import.format", job.Format),
+			attribute.Bool("import.dry_run", job.DryRun),
+		))
+		defer span.End()
+
+		if err := i.process(ctx, jobID, job.Format, path, job.DryRun, mapping); err != nil {
+			log.Printf("Import job %d failed: %v", jobID, err)
+			span.SetStatus(codes.Error, err.Error())
+			if err := i.jobs.MarkFailed(ctx, jobID, err.Error()); err != nil {
+				log.Printf("Failed to mark import job %d as failed: %v", jobID, err)
+			}
+			return
+		}
+		if err := i.jobs.MarkCompleted(ctx, jobID); err != nil {
+			log.Printf("Failed to mark import job %d as completed: %v", jobID, err)
+		}
+		log.Printf("Import job %d completed", jobID)
+	}(job.ID)
+
+	return nil
+}
+
+// Shutdown interrupts running imports and waits for them to record their final state
+func (i *Importer) Shutdown() {
+	i.cancel()
+	i.wg.Wait()
+}
+
+func (i *Importer) process(ctx context.Context, jobID int64, format, path string, dryRun bool, mapping ColumnMapping) error {
+	total, err := countRows(path, format, mapping)
+	if err != nil {
+		return err
+	}
+	if err := i.jobs.MarkProcessing(ctx, jobID, total); err != nil {
+		return err
+	}
+
+	r, err := openReader(path, format, mapping.Sheet)
+	if err != nil {
+		return err
+	}
+	defer r.Close() //nolint:errcheck
+
+	rowNum := 0
+	var header []string
+	if mapping.HasHeader {
+		header, err = r.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		rowNum++
+	}
+
+	phoneIdx, err := resolveColumn(mapping.PhoneColumn, header)
+	if err != nil {
+		return err
+	}
+	contentIdx, err := resolveColumn(mapping.ContentColumn, header)
+	if err != nil {
+		return err
+	}
+
+	c := &chunk{ctx: ctx, repo: i.repo, jobs: i.jobs, jobID: jobID, dryRun: dryRun}
+	for {
+		if err := i.ctx.Err(); err != nil {
+			return fmt.Errorf("import interrupted by shutdown")
+		}
+
+		row, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		rowNum++
+		if err != nil {
+			// A malformed line does not make the rest of the file unreadable for CSV
+			c.reject(rowNum, "Unreadable row: "+err.Error(), nil)
+			continue
+		}
+		if isBlank(row) {
+			continue
+		}
+
+		phone := cell(row, phoneIdx)
+		content := cell(row, contentIdx)
+		if errs := validator.ValidateMessage(phone, content); len(errs) > 0 {
+			c.reject(rowNum, "Validation failed", errs)
+		} else {
+			c.add(rowNum, model.Message{PhoneNumber: phone, Content: content})
+		}
+
+		if c.processed >= chunkSize {
+			if err := c.flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return c.flush()
+}
+
+// chunk accumulates one chunk of rows before inserting them and reporting progress
+type chunk struct {
+	ctx      context.Context
+	repo     *repository.MessageRepository
+	jobs     *repository.ImportJobRepository
+	jobID    int64
+	dryRun   bool
+	msgs     []model.Message
+	rows     []int
+	rejected []model.ImportRejection
+
+	processed   int
+	rejectCount int
+	stored      int
+}
+
+func (c *chunk) add(row int, m model.Message) {
+	c.msgs = append(c.msgs, m)
+	c.rows = append(c.rows, row)
+	c.processed++
+}
+
+func (c *chunk) reject(row int, reason string, errs []model.FieldError) {
+	c.processed++
+	c.rejectCount++
+	// Keep the job row bounded for very dirty files; the counter still reflects every rejection
+	if c.stored < maxStoredRejections {
+		c.rejected = append(c.rejected, model.ImportRejection{Row: row, Reason: reason, Errors: errs})
+		c.stored++
+	}
+}
+
+func (c *chunk) flush() error {
+	if c.processed == 0 {
+		return nil
+	}
+
+	accepted := len(c.msgs)
+	if !c.dryRun && len(c.msgs) > 0 {
+		err := c.repo.CreateBatch(c.ctx, c.msgs)
+		if err != nil && !errors.Is(err, repository.ErrBatchNotStored) {
+			// The chunk may have been stored, inserting it again could duplicate messages
+			return fmt.Errorf("chunk insert failed after the statement ran: %w", err)
+		}
+		if err != nil {
+			// Nothing of the chunk was stored, so fall back to row-by-row inserts to isolate the bad rows
+			log.Printf("Import job %d: chunk insert failed, retrying row by row: %v", c.jobID, err)
+			accepted = 0
+			for idx := range c.msgs {
+				if err := c.repo.Create(c.ctx, &c.msgs[idx]); err != nil {
+					c.reject(c.rows[idx], "Failed to store message", nil)
+					c.processed-- // already counted when added
+					continue
+				}
+				accepted++
+			}
+		}
+	}
+
+	if err := c.jobs.AddProgress(c.ctx, c.jobID, c.processed, accepted, c.rejectCount, c.rejected); err != nil {
+		return err
+	}
+
+	c.msgs = c.msgs[:0]
+	c.rows = c.rows[:0]
+	c.rejected = c.rejected[:0]
+	c.processed = 0
+	c.rejectCount = 0
+	return nil
+}
+
+func countRows(path, format string, mapping ColumnMapping) (int, error) {
+	r, err := openReader(path, format, mapping.Sheet)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close() //nolint:errcheck
+
+	total := 0
+	for {
+		row, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil || !isBlank(row) {
+			total++
+		}
+	}
+	if mapping.HasHeader && total > 0 {
+		total--
+	}
+	return total, nil
+}
+
+// resolveColumn maps a column spec to a zero-based index, using the header row when the spec is not a number
+func resolveColumn(spec string, header []string) (int, error) {
+	spec = strings.TrimSpace(spec)
+	if n, err := strconv.Atoi(spec); err == nil {
+		if n < 1 {
+			return 0, fmt.Errorf("column number must be 1 or greater, got %d", n)
+		}
+		return n - 1, nil
+	}
+	if header == nil {
+		return 0, fmt.Errorf("column %q must be a column number when the file has no header row", spec)
+	}
+	for idx, name := range header {
+		if strings.EqualFold(strings.TrimSpace(name), spec) {
+			return idx, nil
+		}
+	}
+	return 0, fmt.Errorf("column %q not found in header row", spec)
+}
+
+func cell(row []string, idx int) string {
+	if idx >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[idx])
+}
+
+func isBlank(row []string) bool {
+	for _, v := range row {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
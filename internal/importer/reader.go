@@ -0,0 +1,100 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"insider-message-sender/internal/constants"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// rowReader iterates over the rows of an uploaded spreadsheet
+type rowReader interface {
+	Next() ([]string, error)
+	Close() error
+}
+
+func openReader(path, format, sheet string) (rowReader, error) {
+	switch format {
+	case constants.ImportFormatCSV:
+		return openCSV(path)
+	case constants.ImportFormatXLSX:
+		return openXLSX(path, sheet)
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+type csvReader struct {
+	file  *os.File
+	r     *csv.Reader
+	first bool
+}
+
+func openCSV(path string) (*csvReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	return &csvReader{file: f, r: r, first: true}, nil
+}
+
+func (c *csvReader) Next() ([]string, error) {
+	record, err := c.r.Read()
+	if err != nil {
+		return nil, err
+	}
+	if c.first && len(record) > 0 {
+		// Excel writes a UTF-8 BOM at the start of CSV exports
+		record[0] = strings.TrimPrefix(record[0], "\ufeff")
+		c.first = false
+	}
+	return record, nil
+}
+
+func (c *csvReader) Close() error {
+	return c.file.Close()
+}
+
+type xlsxReader struct {
+	file *excelize.File
+	rows *excelize.Rows
+}
+
+func openXLSX(path, sheet string) (*xlsxReader, error) {
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if sheet == "" {
+		sheet = f.GetSheetName(0)
+	}
+	rows, err := f.Rows(sheet)
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("sheet %q: %w", sheet, err)
+	}
+	return &xlsxReader{file: f, rows: rows}, nil
+}
+
+func (x *xlsxReader) Next() ([]string, error) {
+	if !x.rows.Next() {
+		if err := x.rows.Error(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	return x.rows.Columns()
+}
+
+func (x *xlsxReader) Close() error {
+	_ = x.rows.Close()
+	return x.file.Close()
+}
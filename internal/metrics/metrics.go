@@ -0,0 +1,136 @@
+package metrics
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"insider-message-sender/internal/constants"
+	"insider-message-sender/internal/repository"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "insider"
+
+// Send outcomes of a single provider call
+const (
+	OutcomeSent      = "sent"
+	OutcomeTransient = "transient_error"
+	OutcomePermanent = "permanent_error"
+)
+
+var (
+	// SchedulerTicks counts sending loop iterations
+	SchedulerTicks = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "scheduler",
+		Name:      "ticks_total",
+		Help:      "Number of scheduler ticks.",
+	})
+
+	// BatchSize observes how many messages each tick claimed
+	BatchSize = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "scheduler",
+		Name:      "batch_size",
+		Help:      "Number of messages claimed per tick.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+	})
+
+	sends = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "provider",
+		Name:      "sends_total",
+		Help:      "Provider calls by provider, outcome and HTTP status code.",
+	}, []string{"provider", "outcome", "status_code"})
+
+	sendDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "provider",
+		Name:      "send_duration_seconds",
+		Help:      "Latency of provider calls by provider and outcome.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"provider", "outcome"})
+
+	// Retries counts failed attempts scheduled for another try
+	Retries = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "scheduler",
+		Name:      "retries_total",
+		Help:      "Number of failed attempts rescheduled for a retry.",
+	})
+)
+
+// ObserveSend records one provider call; statusCode is zero when no response was received
+func ObserveSend(provider, outcome string, statusCode int, latency time.Duration) {
+	code := "none"
+	if statusCode != 0 {
+		code = strconv.Itoa(statusCode)
+	}
+	sends.WithLabelValues(provider, outcome, code).Inc()
+	sendDuration.WithLabelValues(provider, outcome).Observe(latency.Seconds())
+}
+
+// RegisterStateCollectors exposes message counts, the scheduler state and the database pool, all read at scrape time
+func RegisterStateCollectors(repo *repository.MessageRepository, running func() bool) {
+	prometheus.MustRegister(
+		&messageCountCollector{repo: repo},
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "scheduler",
+			Name:      "running",
+			Help:      "Whether the scheduler is running (1) or stopped (0).",
+		}, func() float64 {
+			if running() {
+				return 1
+			}
+			return 0
+		}),
+		collectors.NewDBStatsCollector(repo.DB(), "messages"),
+	)
+}
+
+var messagesDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "", "messages"),
+	"Number of messages by status.",
+	[]string{"status"}, nil,
+)
+
+// messageCountCollector reports the pending, sent and failed backlog from the database on every scrape
+type messageCountCollector struct {
+	repo *repository.MessageRepository
+}
+
+// countTimeout bounds the count queries of a scrape, so a slow database cannot pile up scrapes
+const countTimeout = 5 * time.Second
+
+func (c *messageCountCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- messagesDesc
+}
+
+func (c *messageCountCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), countTimeout)
+	defer cancel()
+
+	counts := []struct {
+		status string
+		count  func(context.Context) (int, error)
+	}{
+		{constants.MessageStatusPending, c.repo.CountPending},
+		{constants.MessageStatusSent, c.repo.CountSent},
+		{constants.MessageStatusFailed, c.repo.CountFailed},
+	}
+	for _, s := range counts {
+		n, err := s.count(ctx)
+		if err != nil {
+			log.Printf("Failed to count %s messages for metrics: %v", s.status, err)
+			ch <- prometheus.NewInvalidMetric(messagesDesc, err)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(messagesDesc, prometheus.GaugeValue, float64(n), s.status)
+	}
+}
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+type ImportRejection struct {
+	Row    int          `json:"row" example:"3"`
+	Reason string       `json:"reason" example:"Validation failed"`
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+type ImportJob struct {
+	ID            int64             `json:"id" example:"1"`
+	Filename      string            `json:"filename" example:"campaign.csv"`
+	Format        string            `json:"format" example:"csv"`
+	Status        string            `json:"status" example:"processing"`
+	DryRun        bool              `json:"dry_run" example:"false"`
+	TotalRows     int               `json:"total_rows" example:"1000"`
+	ProcessedRows int               `json:"processed_rows" example:"500"`
+	AcceptedRows  int               `json:"accepted_rows" example:"498"`
+	RejectedRows  int               `json:"rejected_rows" example:"2"`
+	Rejections    []ImportRejection `json:"rejections"`
+	Error         string            `json:"error,omitempty" example:""`
+	CreatedAt     time.Time         `json:"created_at" example:"2025-10-19T07:41:45Z"`
+	UpdatedAt     time.Time         `json:"updated_at" example:"2025-10-19T07:41:50Z"`
+	CompletedAt   *time.Time        `json:"completed_at,omitempty" example:"2025-10-19T07:42:10Z"`
+}
@@ -3,9 +3,126 @@ package model
 import "time"
 
 type Message struct {
-	ID          int64     `json:"id"`
-	PhoneNumber string    `json:"phone_number"`
-	Content     string    `json:"content"`
-	Status      string    `json:"status"`
-	SentAt      time.Time `json:"sent_at"`
+	ID          int64      `json:"id"`
+	PhoneNumber string     `json:"phone_number"`
+	Content     string     `json:"content"`
+	Status      string     `json:"status"`
+	Priority    string     `json:"priority"`
+	SentAt      *time.Time `json:"sent_at,omitempty"`
+	FailedAt    *time.Time `json:"failed_at,omitempty"`
+	SendAt      time.Time  `json:"send_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+
+	AttemptCount  int        `json:"attempt_count"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+
+	ErrorClass        string `json:"error_class,omitempty"`
+	ProviderErrorCode string `json:"provider_error_code,omitempty"`
+
+	// Provider is the gateway used by the last delivery attempt
+	Provider string `json:"provider,omitempty"`
+	// ProviderMessageID is the gateway's identifier of a sent message
+	ProviderMessageID string `json:"provider_message_id,omitempty"`
+	// LastStatusCode is the HTTP status of the last provider response, zero if none was received
+	LastStatusCode int `json:"last_status_code,omitempty"`
+	// DeliveryReportedAt and DeliveryReason come from the provider's delivery receipt
+	DeliveryReportedAt *time.Time `json:"delivery_reported_at,omitempty"`
+	DeliveryReason     string     `json:"delivery_reason,omitempty"`
+	// IdempotencyKey is the client-supplied key the message was created with
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+}
+
+// MessageFilter selects messages to list or export; empty fields do not filter
+type MessageFilter struct {
+	Statuses    []string
+	PhoneNumber string
+	// Content matches messages containing it, ignoring case
+	Content     string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	SentFrom    *time.Time
+	SentTo      *time.Time
+	Provider    string
+}
+
+// MessageSort orders messages by one field, with ties broken by id in the same direction
+type MessageSort struct {
+	Field string
+	Desc  bool
+}
+
+// MessageCursor is the keyset position of the last message of a page
+type MessageCursor struct {
+	// Value is the sort field of that message, nil when sorting by id
+	Value *time.Time
+	ID    int64
+}
+
+// RequeueFilter selects failed messages to send back to pending; empty fields do not filter
+type RequeueFilter struct {
+	IDs        []int64
+	FailedFrom *time.Time
+	FailedTo   *time.Time
+	ErrorClass string
+	Limit      int
+}
+
+// Requeue is an audit record of a failed message sent back to pending
+type Requeue struct {
+	ID                   int64     `json:"id" example:"1"`
+	MessageID            int64     `json:"message_id" example:"42"`
+	PreviousAttemptCount int       `json:"previous_attempt_count" example:"3"`
+	PreviousError        string    `json:"previous_error,omitempty" example:"webhook responded 503 Service Unavailable"`
+	PreviousErrorClass   string    `json:"previous_error_class,omitempty" example:"transient"`
+	RequestedBy          string    `json:"requested_by" example:"ops@insider.com"`
+	Reason               string    `json:"reason,omitempty" example:"Provider outage resolved"`
+	RequeuedAt           time.Time `json:"requeued_at" example:"2025-10-19T09:00:00Z"`
+}
+
+// SentOutcome is everything recorded about a message a provider accepted
+type SentOutcome struct {
+	MessageID         int64     `json:"message_id"`
+	Attempts          int       `json:"attempts"`
+	Provider          string    `json:"provider"`
+	ProviderMessageID string    `json:"provider_message_id,omitempty"`
+	StatusCode        int       `json:"status_code,omitempty"`
+	SentAt            time.Time `json:"sent_at"`
+	// Note explains anything unusual about an accepted send, such as an unreadable provider response
+	Note string `json:"note,omitempty"`
+}
+
+// DeliveryReport is a provider's delivery receipt for a sent message
+type DeliveryReport struct {
+	MessageID  int64
+	Status     string
+	ReportedAt time.Time
+	Reason     string
+}
+
+// MessageEvent is one entry of a message's append-only timeline
+type MessageEvent struct {
+	ID        int64  `json:"id" example:"7"`
+	MessageID int64  `json:"message_id" example:"42"`
+	Type      string `json:"type" example:"attempt" enums:"created,claimed,attempt,sent,retry_scheduled,deferred,released,failed,expired,requeued,delivered,undelivered"`
+	// Attempt is the delivery attempt the event belongs to
+	Attempt    int    `json:"attempt,omitempty" example:"1"`
+	Provider   string `json:"provider,omitempty" example:"primary"`
+	StatusCode int    `json:"status_code,omitempty" example:"503"`
+	// LatencyMs is how long the provider took to answer an attempt
+	LatencyMs int       `json:"latency_ms,omitempty" example:"184"`
+	Detail    string    `json:"detail,omitempty" example:"webhook responded 503 Service Unavailable"`
+	CreatedAt time.Time `json:"created_at" example:"2025-10-19T07:41:45Z"`
+}
+
+// SendFailure describes the outcome of a failed delivery attempt
+type SendFailure struct {
+	Attempts     int
+	Error        string
+	ErrorClass   string
+	ProviderCode string
+	Provider     string
+	// StatusCode is the HTTP status of the provider response, zero if none was received
+	StatusCode int
 }
@@ -0,0 +1,68 @@
+package model
+
+import "time"
+
+type CreateMessageRequest struct {
+	PhoneNumber string `json:"phone_number" example:"+84901234567"`
+	Content     string `json:"content" example:"Hello from Insider!"`
+	// Priority defaults to normal; high priority messages are claimed first
+	Priority string `json:"priority,omitempty" example:"normal" enums:"high,normal,low"`
+	// SendAt schedules the message for later; it is sent as soon as possible when omitted
+	SendAt *time.Time `json:"send_at,omitempty" example:"2025-10-20T09:00:00Z"`
+	// ExpiresAt drops the message instead of sending it once this time has passed
+	ExpiresAt *time.Time `json:"expires_at,omitempty" example:"2025-10-20T09:05:00Z"`
+}
+
+// ListMessagesRequest holds the query parameters shared by the message listing and export endpoints
+type ListMessagesRequest struct {
+	// Status may be repeated or comma-separated
+	Status      []string   `form:"status"`
+	PhoneNumber string     `form:"phone_number"`
+	Content     string     `form:"content"`
+	CreatedFrom *time.Time `form:"created_from" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedTo   *time.Time `form:"created_to" time_format:"2006-01-02T15:04:05Z07:00"`
+	SentFrom    *time.Time `form:"sent_from" time_format:"2006-01-02T15:04:05Z07:00"`
+	SentTo      *time.Time `form:"sent_to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Provider    string     `form:"provider"`
+	// Sort is a field name, prefixed with "-" for descending order
+	Sort   string `form:"sort"`
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit"`
+	// Offset is only accepted by the sent, failed and expired listings, which paginated by offset before cursors
+	Offset *int `form:"offset"`
+}
+
+// RequeueMessagesRequest selects failed messages either by id or by filter
+type RequeueMessagesRequest struct {
+	IDs        []int64    `json:"ids,omitempty" example:"1,2,3"`
+	FailedFrom *time.Time `json:"failed_from,omitempty" example:"2025-10-19T00:00:00Z"`
+	FailedTo   *time.Time `json:"failed_to,omitempty" example:"2025-10-20T00:00:00Z"`
+	ErrorClass string     `json:"error_class,omitempty" example:"transient" enums:"transient,permanent"`
+	// Limit caps how many messages one filter request requeues
+	Limit       int    `json:"limit,omitempty" example:"1000"`
+	RequestedBy string `json:"requested_by,omitempty" example:"ops@insider.com"`
+	Reason      string `json:"reason,omitempty" example:"Provider outage resolved"`
+}
+
+type RequeueMessageRequest struct {
+	RequestedBy string `json:"requested_by,omitempty" example:"ops@insider.com"`
+	Reason      string `json:"reason,omitempty" example:"Customer confirmed number"`
+}
+
+// DeliveryReportRequest is a provider's delivery receipt, keyed by the messageId it returned on send
+type DeliveryReportRequest struct {
+	MessageID string `json:"messageId" example:"67f2f8a8-ea58-4ed0-a6f9-ff217df4d849"`
+	// Provider narrows the lookup when several providers may have issued the messageId
+	Provider string `json:"provider,omitempty" example:"primary"`
+	Status   string `json:"status" example:"delivered" enums:"delivered,undelivered"`
+	// Timestamp is when the provider observed the outcome; the time of receipt is used when omitted
+	Timestamp *time.Time `json:"timestamp,omitempty" example:"2025-10-19T07:41:52Z"`
+	Reason    string     `json:"reason,omitempty" example:"DELIVRD"`
+}
+
+// UpdateSchedulerConfigRequest changes only the fields that are provided
+type UpdateSchedulerConfigRequest struct {
+	BatchSize      *int    `json:"batch_size,omitempty" example:"50"`
+	MaxConcurrency *int    `json:"max_concurrency,omitempty" example:"10"`
+	SendInterval   *string `json:"send_interval,omitempty" example:"30s"`
+}
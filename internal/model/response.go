@@ -3,30 +3,155 @@ package model
 import "time"
 
 type SentMessageResponseData struct {
-	ID          int64     `json:"id" example:"1"`
-	PhoneNumber string    `json:"phone_number" example:"+84901234567"`
-	Content     string    `json:"content" example:"Hello from Insider!"`
-	Status      string    `json:"status" example:"sent"`
-	SentAt      time.Time `json:"sent_at" example:"2025-10-19T07:41:45Z"`
+	ID          int64      `json:"id" example:"1"`
+	PhoneNumber string     `json:"phone_number" example:"+84901234567"`
+	Content     string     `json:"content" example:"Hello from Insider!"`
+	Status      string     `json:"status" example:"sent"`
+	Priority    string     `json:"priority" example:"normal"`
+	SentAt      *time.Time `json:"sent_at,omitempty" example:"2025-10-19T07:41:45Z"`
+	FailedAt    *time.Time `json:"failed_at,omitempty" example:"2025-10-19T07:43:10Z"`
+	SendAt      time.Time  `json:"send_at" example:"2025-10-19T07:40:00Z"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty" example:"2025-10-19T07:45:00Z"`
+	CreatedAt   time.Time  `json:"created_at" example:"2025-10-19T07:39:12Z"`
+
+	AttemptCount  int        `json:"attempt_count" example:"1"`
+	LastError     string     `json:"last_error,omitempty" example:"webhook responded 503 Service Unavailable"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty" example:"2025-10-19T07:42:15Z"`
+
+	ErrorClass        string `json:"error_class,omitempty" example:"permanent" enums:"transient,permanent"`
+	ProviderErrorCode string `json:"provider_error_code,omitempty" example:"21211"`
+
+	Provider          string `json:"provider,omitempty" example:"primary"`
+	ProviderMessageID string `json:"provider_message_id,omitempty" example:"67f2f8a8-ea58-4ed0-a6f9-ff217df4d849"`
+	LastStatusCode    int    `json:"last_status_code,omitempty" example:"202"`
+
+	DeliveryReportedAt *time.Time `json:"delivery_reported_at,omitempty" example:"2025-10-19T07:41:52Z"`
+	DeliveryReason     string     `json:"delivery_reason,omitempty" example:"DELIVRD"`
+
+	IdempotencyKey string `json:"idempotency_key,omitempty" example:"order-1234-otp"`
+}
+
+type MessageResponseData struct {
+	ID          int64      `json:"id" example:"1"`
+	PhoneNumber string     `json:"phone_number" example:"+84901234567"`
+	Content     string     `json:"content" example:"Hello from Insider!"`
+	Status      string     `json:"status" example:"pending"`
+	Priority    string     `json:"priority" example:"normal"`
+	SendAt      time.Time  `json:"send_at" example:"2025-10-19T07:40:00Z"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty" example:"2025-10-19T07:45:00Z"`
+
+	IdempotencyKey string `json:"idempotency_key,omitempty" example:"order-1234-otp"`
+}
+
+type MessageStatsResponse struct {
+	Counts map[string]int `json:"counts" example:"pending:3,in_progress:0,sent:10,failed:1,expired:2"`
+	Time   string         `json:"time" example:"2025-10-19T09:00:00Z"`
+}
+
+type CreateMessageResponse struct {
+	Data MessageResponseData `json:"data"`
+}
+
+type BatchAcceptedItem struct {
+	Index int   `json:"index" example:"0"`
+	ID    int64 `json:"id" example:"42"`
+	// Replayed is set when the item's idempotency key matched an already stored message
+	Replayed bool `json:"replayed,omitempty" example:"false"`
+}
+
+type BatchRejectedItem struct {
+	Index  int          `json:"index" example:"1"`
+	Reason string       `json:"reason" example:"Validation failed"`
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+type BatchCreateMessagesResponse struct {
+	AcceptedCount int                 `json:"accepted_count" example:"1"`
+	RejectedCount int                 `json:"rejected_count" example:"1"`
+	Accepted      []BatchAcceptedItem `json:"accepted"`
+	Rejected      []BatchRejectedItem `json:"rejected"`
+}
+
+type ImportJobResponse struct {
+	Data ImportJob `json:"data"`
+}
+
+type RequeueResponse struct {
+	RequeuedCount int     `json:"requeued_count" example:"2"`
+	IDs           []int64 `json:"ids" example:"1,2"`
+	Time          string  `json:"time" example:"2025-10-19T09:00:00Z"`
+}
+
+type RequeueHistoryResponse struct {
+	Data []Requeue `json:"data"`
 }
 
 type Pagination struct {
-	Limit   int  `json:"limit" example:"10"`
-	Offset  int  `json:"offset" example:"0"`
-	Count   int  `json:"count" example:"2"`
-	Total   int  `json:"total" example:"5"`
+	Limit int `json:"limit" example:"10"`
+	// Offset and Total are only set by the sent, failed and expired listings
+	Offset  *int `json:"offset,omitempty" example:"0"`
+	Count   int  `json:"count" example:"10"`
+	Total   *int `json:"total,omitempty" example:"125"`
 	HasMore bool `json:"has_more" example:"true"`
+	// NextCursor fetches the following page when passed as cursor; it is empty on the last page
+	NextCursor string `json:"next_cursor,omitempty" example:"eyJzIjoiLWNyZWF0ZWRfYXQiLCJ2IjoiMjAyNS0xMC0xOVQwNzozOToxMloiLCJpZCI6NDJ9"`
 }
 
-type SentMessagesResponse struct {
+type MessagesResponse struct {
 	Data       []SentMessageResponseData `json:"data"`
 	Pagination Pagination                `json:"pagination"`
 }
 
+type MessageDetailResponse struct {
+	Data SentMessageResponseData `json:"data"`
+}
+
+type MessageEventsResponse struct {
+	Data []MessageEvent `json:"data"`
+}
+
+type DeliveryReportResponse struct {
+	Status string `json:"status" example:"success"`
+	// ID is our id of the reported message
+	ID int64 `json:"id" example:"42"`
+	// DeliveryStatus is the message's status after the report
+	DeliveryStatus string `json:"delivery_status" example:"delivered"`
+	// Applied is false when the report was older than the one already recorded
+	Applied bool   `json:"applied" example:"true"`
+	Time    string `json:"time" example:"2025-10-19T07:41:52Z"`
+}
+
 type SchedulerActionResponse struct {
-	Status  string `json:"status" example:"success"`
-	Message string `json:"message" example:"Scheduler started successfully"`
-	Time    string `json:"time" example:"2025-10-19T08:10:00Z"`
+	Status   string `json:"status" example:"success"`
+	Message  string `json:"message" example:"Scheduler started successfully"`
+	Instance string `json:"instance" example:"app-1-7"`
+	Leader   string `json:"leader" example:"app-1-7"`
+	Time     string `json:"time" example:"2025-10-19T08:10:00Z"`
+}
+
+type SchedulerStatusResponse struct {
+	Running  bool              `json:"running" example:"true"`
+	Role     string            `json:"role" example:"leader" enums:"leader,standby,stopped"`
+	Instance string            `json:"instance" example:"app-1-7"`
+	Leader   string            `json:"leader" example:"app-1-7"`
+	Circuits []CircuitResponse `json:"circuits"`
+	// ReconciliationBacklog counts accepted sends waiting to be recorded in the database, -1 if unknown
+	ReconciliationBacklog int64  `json:"reconciliation_backlog" example:"0"`
+	Time                  string `json:"time" example:"2025-10-19T08:10:00Z"`
+}
+
+type CircuitResponse struct {
+	Provider            string     `json:"provider" example:"primary"`
+	State               string     `json:"state" example:"open" enums:"closed,open,half_open"`
+	ConsecutiveFailures int        `json:"consecutive_failures" example:"5"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty" example:"2025-10-19T08:09:30Z"`
+	RetryAt             *time.Time `json:"retry_at,omitempty" example:"2025-10-19T08:10:00Z"`
+}
+
+type SchedulerConfigResponse struct {
+	BatchSize      int    `json:"batch_size" example:"50"`
+	MaxConcurrency int    `json:"max_concurrency" example:"10"`
+	SendInterval   string `json:"send_interval" example:"30s"`
 }
 
 type ErrorResponse struct {
@@ -35,8 +160,20 @@ type ErrorResponse struct {
 	Time    string `json:"time" example:"2025-10-19T09:00:00Z"`
 }
 
+type FieldError struct {
+	Field   string `json:"field" example:"phone_number"`
+	Message string `json:"message" example:"must be in E.164 format, e.g. +84901234567"`
+}
+
+type ValidationErrorResponse struct {
+	Status  string       `json:"status" example:"error"`
+	Message string       `json:"message" example:"Validation failed"`
+	Errors  []FieldError `json:"errors"`
+	Time    string       `json:"time" example:"2025-10-19T09:00:00Z"`
+}
+
 type HealthResponse struct {
 	Status    string            `json:"status" example:"healthy"`
 	Timestamp string            `json:"timestamp" example:"2025-10-19T09:00:00Z"`
-	Services  map[string]string `json:"services" example:"database:healthy,scheduler:running,redis:healthy"`
+	Services  map[string]string `json:"services" example:"database:healthy,scheduler:running,redis:healthy,instance:app-1-7,leader:app-1-7,circuit:primary:closed"`
 }
@@ -0,0 +1,122 @@
+package provider
+
+import (
+	"sync"
+	"time"
+
+	"insider-message-sender/internal/constants"
+)
+
+// CircuitBreaker stops sending to a provider after consecutive transient failures. Once open it rejects
+// sends for openTimeout, then lets a single probe through: a successful probe closes the circuit,
+// a failed one opens it again.
+type CircuitBreaker struct {
+	threshold   int
+	openTimeout time.Duration
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// CircuitStatus is a snapshot of a circuit breaker
+type CircuitStatus struct {
+	State               string
+	ConsecutiveFailures int
+	OpenedAt            time.Time
+	// RetryAt is when an open circuit lets the next probe through
+	RetryAt time.Time
+}
+
+func NewCircuitBreaker(threshold int, openTimeout time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		threshold:   threshold,
+		openTimeout: openTimeout,
+		state:       constants.CircuitClosed,
+	}
+}
+
+// Allow reports whether a send may go through. In half-open state only one probe is admitted
+// until its outcome is recorded.
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case constants.CircuitOpen:
+		if time.Since(b.openedAt) < b.openTimeout {
+			return false
+		}
+		b.state = constants.CircuitHalfOpen
+		b.probing = true
+		return true
+	case constants.CircuitHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// Available reports whether a send could go through now without admitting it
+func (b *CircuitBreaker) Available() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case constants.CircuitOpen:
+		return time.Since(b.openedAt) >= b.openTimeout
+	case constants.CircuitHalfOpen:
+		return !b.probing
+	default:
+		return true
+	}
+}
+
+// Success records a send that reached the provider, closing the circuit
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = constants.CircuitClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// Failure records a transient failure and reports whether it left the circuit open
+func (b *CircuitBreaker) Failure() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == constants.CircuitHalfOpen || b.failures >= b.threshold {
+		b.state = constants.CircuitOpen
+		b.openedAt = time.Now()
+		b.probing = false
+	}
+	return b.state == constants.CircuitOpen
+}
+
+// Release gives up an admitted send that never reached the provider, e.g. because it was cancelled
+func (b *CircuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+func (b *CircuitBreaker) Status() CircuitStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	st := CircuitStatus{State: b.state, ConsecutiveFailures: b.failures}
+	if b.state != constants.CircuitClosed {
+		st.OpenedAt = b.openedAt
+		st.RetryAt = b.openedAt.Add(b.openTimeout)
+	}
+	return st
+}
@@ -0,0 +1,93 @@
+package provider
+
+import (
+	"testing"
+	"time"
+
+	"insider-message-sender/internal/constants"
+)
+
+// elapse moves an open circuit's open time back, as if its timeout had passed
+func elapse(b *CircuitBreaker) {
+	b.mu.Lock()
+	b.openedAt = b.openedAt.Add(-b.openTimeout)
+	b.mu.Unlock()
+}
+
+func TestCircuitOpensAfterThreshold(t *testing.T) {
+	b := NewCircuitBreaker(3, time.Minute)
+	for i := 1; i < 3; i++ {
+		if b.Failure() {
+			t.Fatalf("circuit opened after %d failures, threshold is 3", i)
+		}
+	}
+	if !b.Failure() {
+		t.Fatal("circuit stayed closed after 3 failures")
+	}
+
+	if b.Allow() || b.Available() {
+		t.Fatal("open circuit let a send through")
+	}
+	st := b.Status()
+	if st.State != constants.CircuitOpen || st.ConsecutiveFailures != 3 || !st.RetryAt.Equal(st.OpenedAt.Add(time.Minute)) {
+		t.Fatalf("Status = %+v", st)
+	}
+}
+
+func TestCircuitSuccessResetsFailures(t *testing.T) {
+	b := NewCircuitBreaker(2, time.Minute)
+	b.Failure()
+	b.Success()
+	if b.Failure() {
+		t.Fatal("failures before a success counted towards the threshold")
+	}
+}
+
+func TestCircuitHalfOpenAdmitsOneProbe(t *testing.T) {
+	b := NewCircuitBreaker(1, time.Minute)
+	b.Failure()
+	elapse(b)
+
+	if !b.Available() {
+		t.Fatal("circuit is not available after its timeout")
+	}
+	if !b.Allow() {
+		t.Fatal("probe was not admitted after the timeout")
+	}
+	if b.Status().State != constants.CircuitHalfOpen {
+		t.Fatalf("state = %s, want half-open", b.Status().State)
+	}
+	if b.Allow() || b.Available() {
+		t.Fatal("a second send was admitted while the probe is in flight")
+	}
+
+	// A probe that never reached the provider frees the slot for another one
+	b.Release()
+	if !b.Allow() {
+		t.Fatal("probe was not admitted after the previous one was released")
+	}
+}
+
+func TestCircuitProbeOutcome(t *testing.T) {
+	b := NewCircuitBreaker(3, time.Minute)
+	for i := 0; i < 3; i++ {
+		b.Failure()
+	}
+	elapse(b)
+	b.Allow()
+
+	// A failed probe reopens the circuit at once, regardless of the threshold
+	if !b.Failure() || b.Status().State != constants.CircuitOpen || b.Allow() {
+		t.Fatal("failed probe did not reopen the circuit")
+	}
+
+	elapse(b)
+	b.Allow()
+	b.Success()
+	if st := b.Status(); st.State != constants.CircuitClosed || st.ConsecutiveFailures != 0 || !st.RetryAt.IsZero() {
+		t.Fatalf("Status after a successful probe = %+v, want closed", st)
+	}
+	if !b.Allow() || !b.Allow() {
+		t.Fatal("closed circuit rejected a send")
+	}
+}
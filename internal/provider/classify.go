@@ -0,0 +1,121 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"insider-message-sender/internal/constants"
+)
+
+// maxErrorBodySize bounds how much of an error response is read when looking for a provider error code
+const maxErrorBodySize = 4 << 10
+
+// SendError is a failed delivery attempt classified as retryable or permanent
+type SendError struct {
+	Class string
+	// Code is the provider's own error code, or the HTTP status when the provider did not send one
+	Code       string
+	StatusCode int
+	// RetryAfter is the wait requested by the provider, zero when it did not ask for one
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *SendError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *SendError) Unwrap() error {
+	return e.Err
+}
+
+func (e *SendError) Retryable() bool {
+	return e.Class == constants.ErrorClassTransient
+}
+
+// classifyStatus maps a non-2xx HTTP status to an error class: 408, 429 and 5xx are worth retrying, and so is
+// a 3xx, which points at a moved or misconfigured endpoint rather than at the message.
+// Any other 4xx means the request itself is wrong and will fail again.
+func classifyStatus(status int) string {
+	switch {
+	case status >= 300 && status < 400:
+		return constants.ErrorClassTransient
+	case status == http.StatusRequestTimeout, status == http.StatusTooManyRequests, status >= 500:
+		return constants.ErrorClassTransient
+	default:
+		return constants.ErrorClassPermanent
+	}
+}
+
+// classifyResponse builds the error for a non-2xx webhook response
+func classifyResponse(resp *http.Response, body []byte) *SendError {
+	code := providerErrorCode(body)
+	if code == "" {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+
+	return &SendError{
+		Class:      classifyStatus(resp.StatusCode),
+		Code:       code,
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		Err:        fmt.Errorf("webhook responded %s", resp.Status),
+	}
+}
+
+// transientError wraps failures that are not the message's fault, such as network errors
+func transientError(err error) *SendError {
+	return &SendError{Class: constants.ErrorClassTransient, Err: err}
+}
+
+// providerErrorCode extracts an error code from common JSON error shapes such as
+// {"code": 21211}, {"error_code": "INVALID_NUMBER"} or {"error": {"code": "..."}}
+func providerErrorCode(body []byte) string {
+	var payload map[string]any
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return ""
+	}
+
+	for _, key := range []string{"code", "error_code", "errorCode"} {
+		if code := stringify(payload[key]); code != "" {
+			return code
+		}
+	}
+	if nested, ok := payload["error"].(map[string]any); ok {
+		return stringify(nested["code"])
+	}
+	return ""
+}
+
+func stringify(v any) string {
+	switch val := v.(type) {
+	case string:
+		return strings.TrimSpace(val)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
+
+// parseRetryAfter supports both forms of the Retry-After header: delay-seconds and an HTTP date
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,74 @@
+package provider
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"insider-message-sender/internal/constants"
+)
+
+func TestClassifyStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		want   string
+	}{
+		{http.StatusMovedPermanently, constants.ErrorClassTransient},
+		{http.StatusTemporaryRedirect, constants.ErrorClassTransient},
+		{http.StatusBadRequest, constants.ErrorClassPermanent},
+		{http.StatusUnauthorized, constants.ErrorClassPermanent},
+		{http.StatusNotFound, constants.ErrorClassPermanent},
+		{http.StatusRequestTimeout, constants.ErrorClassTransient},
+		{http.StatusUnprocessableEntity, constants.ErrorClassPermanent},
+		{http.StatusTooManyRequests, constants.ErrorClassTransient},
+		{http.StatusInternalServerError, constants.ErrorClassTransient},
+		{http.StatusServiceUnavailable, constants.ErrorClassTransient},
+	}
+	for _, tt := range tests {
+		if got := classifyStatus(tt.status); got != tt.want {
+			t.Errorf("classifyStatus(%d) = %s, want %s", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 10, 19, 8, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"120", 2 * time.Minute},
+		{" 5 ", 5 * time.Second},
+		{"0", 0},
+		{"-3", 0},
+		{"soon", 0},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestProviderErrorCode(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{`{"code": 21211}`, "21211"},
+		{`{"error_code": "INVALID_NUMBER"}`, "INVALID_NUMBER"},
+		{`{"errorCode": " E42 "}`, "E42"},
+		{`{"error": {"code": "blocked"}}`, "blocked"},
+		{`{"message": "bad request"}`, ""},
+		{`not json`, ""},
+		{``, ""},
+	}
+	for _, tt := range tests {
+		if got := providerErrorCode([]byte(tt.body)); got != tt.want {
+			t.Errorf("providerErrorCode(%s) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
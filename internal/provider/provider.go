@@ -0,0 +1,67 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"insider-message-sender/internal/config"
+	"insider-message-sender/internal/model"
+)
+
+// Types of the built-in providers, selected through SMS_PROVIDER or PROVIDERS
+const (
+	Webhook = "webhook"
+)
+
+// Provider delivers messages to an outbound SMS gateway.
+// Send makes a single delivery attempt; failures are returned as *SendError so the caller can decide whether to retry.
+type Provider interface {
+	Name() string
+	Send(ctx context.Context, m model.Message) (*Result, error)
+}
+
+// Result is the gateway's acknowledgement of an accepted message
+type Result struct {
+	// MessageID is the gateway's identifier for the message, empty when it did not return one
+	MessageID  string
+	StatusCode int
+	// ResponseError is set when the gateway accepted the message but its response could not be read.
+	// The message must still be treated as sent, sending it again would deliver it twice.
+	ResponseError error
+}
+
+// ErrCircuitOpen marks a send that was not attempted, or not counted, because the provider circuits are open
+var ErrCircuitOpen = errors.New("provider circuit open")
+
+// New builds the adapter for one configured provider
+func New(pc config.ProviderConfig, sendInterval time.Duration) (Provider, error) {
+	switch pc.Type {
+	case Webhook:
+		if pc.URL == "" {
+			return nil, fmt.Errorf("provider %q needs a webhook URL", pc.Name)
+		}
+		return NewWebhookProvider(pc.Name, pc.URL, sendInterval), nil
+	default:
+		return nil, fmt.Errorf("provider %q has unknown type %q", pc.Name, pc.Type)
+	}
+}
+
+// DeliveryKey is the stable idempotency key sent to providers for a message, the same on every attempt,
+// so a gateway that supports idempotent requests drops a resend of a message it already accepted
+func DeliveryKey(m model.Message) string {
+	return fmt.Sprintf("insider-msg-%d", m.ID)
+}
+
+// AsSendError returns err as a classified send error, treating unclassified errors as transient
+func AsSendError(err error) *SendError {
+	if err == nil {
+		return nil
+	}
+	var sendErr *SendError
+	if errors.As(err, &sendErr) {
+		return sendErr
+	}
+	return transientError(err)
+}
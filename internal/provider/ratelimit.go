@@ -0,0 +1,108 @@
+package provider
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"insider-message-sender/internal/cache"
+)
+
+const rateLimitKeyPrefix = "insider:ratelimit"
+
+// RateLimiter paces sends to a provider
+type RateLimiter interface {
+	// Wait blocks until a send may go out, returning early with ctx's error if ctx is cancelled
+	Wait(ctx context.Context) error
+}
+
+// unlimited is used for providers without a configured rate limit
+type unlimited struct{}
+
+func (unlimited) Wait(context.Context) error { return nil }
+
+// TokenBucket allows rate sends per second on average with bursts of up to burst sends.
+// Callers reserve a token up front and sleep until it is due, so concurrent senders are served in order.
+type TokenBucket struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	return &TokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	return sleep(ctx, b.reserve())
+}
+
+// reserve takes a token, letting the bucket go into debt, and returns how long until the token is due
+func (b *TokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate) - 1
+	b.last = now
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// SharedTokenBucket keeps the bucket in Redis so that all replicas share a provider's limit.
+// When Redis is unreachable it falls back to a local bucket rather than stop sending.
+type SharedTokenBucket struct {
+	cache    *cache.RedisClient
+	key      string
+	rate     float64
+	burst    int
+	fallback *TokenBucket
+}
+
+func NewSharedTokenBucket(cache *cache.RedisClient, provider string, rate float64, burst int) *SharedTokenBucket {
+	return &SharedTokenBucket{
+		cache:    cache,
+		key:      rateLimitKeyPrefix + ":" + provider,
+		rate:     rate,
+		burst:    burst,
+		fallback: NewTokenBucket(rate, burst),
+	}
+}
+
+func (b *SharedTokenBucket) Wait(ctx context.Context) error {
+	wait, err := b.cache.ReserveToken(ctx, b.key, b.rate, b.burst)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		log.Printf("Shared rate limit for %s unavailable, using local limit: %v", b.key, err)
+		return b.fallback.Wait(ctx)
+	}
+	return sleep(ctx, wait)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
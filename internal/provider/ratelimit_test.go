@@ -0,0 +1,63 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// approx allows for the time that passes between two reservations in a test
+func approx(got, want time.Duration) bool {
+	const tolerance = 20 * time.Millisecond
+	return got >= want-tolerance && got <= want+tolerance
+}
+
+func TestTokenBucketServesBurstThenPacesReservations(t *testing.T) {
+	b := NewTokenBucket(10, 3)
+	for i := 0; i < 3; i++ {
+		if d := b.reserve(); d != 0 {
+			t.Fatalf("reservation %d within the burst waits %s", i+1, d)
+		}
+	}
+
+	// Every reservation beyond the burst is due one interval after the previous one
+	for i, want := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond} {
+		if d := b.reserve(); !approx(d, want) {
+			t.Fatalf("reservation %d waits %s, want about %s", i+4, d, want)
+		}
+	}
+}
+
+func TestTokenBucketRefillsUpToBurst(t *testing.T) {
+	b := NewTokenBucket(10, 2)
+	b.reserve()
+	b.reserve()
+
+	// A long idle period refills the bucket, but never beyond the burst
+	b.mu.Lock()
+	b.last = b.last.Add(-time.Hour)
+	b.mu.Unlock()
+
+	for i := 0; i < 2; i++ {
+		if d := b.reserve(); d != 0 {
+			t.Fatalf("reservation %d after refilling waits %s", i+1, d)
+		}
+	}
+	if d := b.reserve(); !approx(d, 100*time.Millisecond) {
+		t.Fatalf("reservation beyond the refilled burst waits %s, want about 100ms", d)
+	}
+}
+
+func TestTokenBucketWaitStopsOnCancel(t *testing.T) {
+	b := NewTokenBucket(0.001, 1)
+	if err := b.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait within the burst = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := b.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait with an exhausted bucket = %v, want the context error", err)
+	}
+}
@@ -0,0 +1,147 @@
+package provider
+
+import (
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"insider-message-sender/internal/cache"
+	"insider-message-sender/internal/config"
+	"insider-message-sender/internal/model"
+)
+
+// Router picks the providers to try for a message, in order: the routed provider first, then its failovers
+type Router struct {
+	providers map[string]Provider
+	breakers  map[string]*CircuitBreaker
+	limiters  map[string]RateLimiter
+	order     []Provider
+	rules     []config.RouteRule
+}
+
+// ProviderCircuit is the circuit breaker state of one provider
+type ProviderCircuit struct {
+	Provider string
+	CircuitStatus
+}
+
+// NewRouter builds every configured provider with its circuit breaker and rate limiter, and the routing rules
+// between them. The cache is only used when rate limits are shared between replicas.
+func NewRouter(cfg *config.Config, cache *cache.RedisClient) (*Router, error) {
+	r := &Router{
+		providers: make(map[string]Provider, len(cfg.Providers)),
+		breakers:  make(map[string]*CircuitBreaker, len(cfg.Providers)),
+		limiters:  make(map[string]RateLimiter, len(cfg.Providers)),
+		rules:     cfg.Routes,
+	}
+	for _, pc := range cfg.Providers {
+		p, err := New(pc, cfg.SendInterval)
+		if err != nil {
+			return nil, err
+		}
+		r.providers[pc.Name] = p
+		r.breakers[pc.Name] = NewCircuitBreaker(cfg.CircuitFailureThreshold, cfg.CircuitOpenTimeout)
+
+		limit, ok := cfg.RateLimits[pc.Name]
+		switch {
+		case !ok:
+			r.limiters[pc.Name] = unlimited{}
+		case cfg.RateLimitShared:
+			r.limiters[pc.Name] = NewSharedTokenBucket(cache, pc.Name, limit.Rate, limit.Burst)
+		default:
+			r.limiters[pc.Name] = NewTokenBucket(limit.Rate, limit.Burst)
+		}
+		r.order = append(r.order, p)
+	}
+	return r, nil
+}
+
+// Providers returns every configured provider in configuration order
+func (r *Router) Providers() []Provider {
+	return r.order
+}
+
+// Breaker returns the circuit breaker guarding the named provider
+func (r *Router) Breaker(name string) *CircuitBreaker {
+	return r.breakers[name]
+}
+
+// Limiter returns the rate limiter pacing sends to the named provider
+func (r *Router) Limiter(name string) RateLimiter {
+	return r.limiters[name]
+}
+
+// Circuits returns the circuit breaker state of every provider in configuration order
+func (r *Router) Circuits() []ProviderCircuit {
+	out := make([]ProviderCircuit, 0, len(r.order))
+	for _, p := range r.order {
+		out = append(out, ProviderCircuit{Provider: p.Name(), CircuitStatus: r.breakers[p.Name()].Status()})
+	}
+	return out
+}
+
+// Unavailable reports whether none of ps can take a send right now, and if so when the first of them
+// lets a probe through
+func (r *Router) Unavailable(ps []Provider) (time.Time, bool) {
+	var retryAt time.Time
+	for _, p := range ps {
+		b := r.breakers[p.Name()]
+		if b.Available() {
+			return time.Time{}, false
+		}
+		if at := b.Status().RetryAt; retryAt.IsZero() || at.Before(retryAt) {
+			retryAt = at
+		}
+	}
+	return retryAt, len(ps) > 0
+}
+
+// Route returns the providers to try for m. Without a matching rule the first configured provider is used
+// and the others serve as failovers.
+func (r *Router) Route(m model.Message) []Provider {
+	for _, rule := range r.rules {
+		if matches(rule.Match, m) {
+			return r.candidates(rule.Targets)
+		}
+	}
+	return r.order
+}
+
+// candidates picks one of the weighted targets and puts the remaining targets behind it in configured order
+func (r *Router) candidates(targets []config.RouteTarget) []Provider {
+	chosen := pickWeighted(targets)
+	out := make([]Provider, 0, len(targets))
+	out = append(out, r.providers[targets[chosen].Provider])
+	for i, t := range targets {
+		if i != chosen {
+			out = append(out, r.providers[t.Provider])
+		}
+	}
+	return out
+}
+
+func pickWeighted(targets []config.RouteTarget) int {
+	total := 0
+	for _, t := range targets {
+		total += t.Weight
+	}
+	n := rand.IntN(total)
+	for i, t := range targets {
+		if n < t.Weight {
+			return i
+		}
+		n -= t.Weight
+	}
+	return 0
+}
+
+func matches(match string, m model.Message) bool {
+	switch {
+	case match == "*":
+		return true
+	case strings.HasPrefix(match, "priority:"):
+		return m.Priority == strings.TrimPrefix(match, "priority:")
+	default:
+		return strings.HasPrefix(m.PhoneNumber, match)
+	}
+}
@@ -0,0 +1,85 @@
+package provider
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"insider-message-sender/internal/config"
+	"insider-message-sender/internal/model"
+)
+
+type namedProvider string
+
+func (p namedProvider) Name() string {
+	return string(p)
+}
+
+func (p namedProvider) Send(context.Context, model.Message) (*Result, error) {
+	return &Result{}, nil
+}
+
+func testRouter(rules []config.RouteRule, names ...string) *Router {
+	r := &Router{providers: make(map[string]Provider), rules: rules}
+	for _, name := range names {
+		p := namedProvider(name)
+		r.providers[name] = p
+		r.order = append(r.order, p)
+	}
+	return r
+}
+
+func providerNames(ps []Provider) []string {
+	names := make([]string, len(ps))
+	for i, p := range ps {
+		names[i] = p.Name()
+	}
+	return names
+}
+
+func TestPickWeightedFollowsWeights(t *testing.T) {
+	targets := []config.RouteTarget{{Provider: "a", Weight: 80}, {Provider: "b", Weight: 0}, {Provider: "c", Weight: 20}}
+	counts := make([]int, len(targets))
+	const picks = 10000
+	for i := 0; i < picks; i++ {
+		counts[pickWeighted(targets)]++
+	}
+
+	if counts[1] != 0 {
+		t.Errorf("target with weight 0 was picked %d times", counts[1])
+	}
+	// 80/20 within a margin far wider than the sampling noise of 10000 picks
+	if counts[0] < 7500 || counts[0] > 8500 {
+		t.Errorf("target with weight 80 was picked %d of %d times", counts[0], picks)
+	}
+}
+
+func TestRouteUsesFirstMatchingRule(t *testing.T) {
+	r := testRouter([]config.RouteRule{
+		{Match: "+84", Targets: []config.RouteTarget{{Provider: "viettel", Weight: 1}, {Provider: "backup"}}},
+		{Match: "priority:high", Targets: []config.RouteTarget{{Provider: "backup", Weight: 1}, {Provider: "primary"}}},
+	}, "primary", "backup", "viettel")
+
+	tests := []struct {
+		msg  model.Message
+		want []string
+	}{
+		{model.Message{PhoneNumber: "+84901234567", Priority: "high"}, []string{"viettel", "backup"}},
+		{model.Message{PhoneNumber: "+905551234567", Priority: "high"}, []string{"backup", "primary"}},
+		// Without a matching rule the configured order applies
+		{model.Message{PhoneNumber: "+905551234567", Priority: "normal"}, []string{"primary", "backup", "viettel"}},
+	}
+	for _, tt := range tests {
+		if got := providerNames(r.Route(tt.msg)); !slices.Equal(got, tt.want) {
+			t.Errorf("Route(%s, %s) = %v, want %v", tt.msg.PhoneNumber, tt.msg.Priority, got, tt.want)
+		}
+	}
+}
+
+func TestCandidatesPutsUnchosenTargetsBehindInConfiguredOrder(t *testing.T) {
+	r := testRouter(nil, "a", "b", "c")
+	got := providerNames(r.candidates([]config.RouteTarget{{Provider: "a"}, {Provider: "b"}, {Provider: "c", Weight: 1}}))
+	if !slices.Equal(got, []string{"c", "a", "b"}) {
+		t.Fatalf("candidates = %v, want [c a b]", got)
+	}
+}
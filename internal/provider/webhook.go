@@ -0,0 +1,98 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"insider-message-sender/internal/constants"
+	"insider-message-sender/internal/model"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// WebhookProvider posts {"to", "content"} JSON to a webhook and reads the gateway's messageId from the response.
+// Every request carries the message's DeliveryKey in the Idempotency-Key header.
+type WebhookProvider struct {
+	name   string
+	url    string
+	client *http.Client
+}
+
+// NewWebhookProvider keeps idle connections around for at least one send interval so ticks reuse them.
+// Requests are traced and carry the W3C traceparent header of the send attempt.
+// Redirects are not followed: Go would replay the POST as a GET without a body, and the 2xx answer to that
+// GET would record a message as sent that was never delivered. A 3xx is classified as a failed attempt instead.
+func NewWebhookProvider(name, url string, sendInterval time.Duration) *WebhookProvider {
+	return &WebhookProvider{
+		name: name,
+		url:  url,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+			Transport: otelhttp.NewTransport(&http.Transport{
+				TLSHandshakeTimeout:   5 * time.Second,
+				ResponseHeaderTimeout: 5 * time.Second,
+				MaxIdleConns:          10,
+				MaxIdleConnsPerHost:   2,
+				IdleConnTimeout:       sendInterval + 30*time.Second,
+			}),
+		},
+	}
+}
+
+func (p *WebhookProvider) Name() string {
+	return p.name
+}
+
+func (p *WebhookProvider) Send(ctx context.Context, m model.Message) (*Result, error) {
+	body, err := json.Marshal(map[string]string{
+		"to":      m.PhoneNumber,
+		"content": m.Content,
+	})
+	if err != nil {
+		return nil, &SendError{Class: constants.ErrorClassPermanent, Err: fmt.Errorf("failed to marshal message: %w", err)}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.url, bytes.NewBuffer(body))
+	if err != nil {
+		// A broken webhook configuration is not the message's fault
+		return nil, transientError(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", DeliveryKey(m))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, transientError(err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// Read response body to avoid connection leak; the head of it may carry the provider's error code
+		errBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil, classifyResponse(resp, errBody)
+	}
+
+	var respData struct {
+		MessageID string `json:"messageId"`
+	}
+	res := &Result{StatusCode: resp.StatusCode}
+	if resp.StatusCode == http.StatusNoContent {
+		// Accepted without a body, so without a messageId
+		return res, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&respData); err != nil {
+		res.ResponseError = fmt.Errorf("invalid webhook response: %w", err)
+		return res, nil
+	}
+	res.MessageID = respData.MessageID
+	return res, nil
+}
@@ -0,0 +1,81 @@
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"insider-message-sender/internal/constants"
+	"insider-message-sender/internal/model"
+)
+
+var testMessage = model.Message{ID: 7, PhoneNumber: "+905551234567", Content: "hi"}
+
+func TestWebhookSendReadsMessageID(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&body) != nil || body["to"] != testMessage.PhoneNumber {
+			t.Errorf("unexpected request %s %v", r.Method, body)
+		}
+		if got := r.Header.Get("Idempotency-Key"); got != "insider-msg-7" {
+			t.Errorf("Idempotency-Key = %q", got)
+		}
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"messageId": "abc-123"}`))
+	}))
+	defer srv.Close()
+
+	res, err := NewWebhookProvider("primary", srv.URL, time.Minute).Send(t.Context(), testMessage)
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if res.MessageID != "abc-123" || res.StatusCode != http.StatusAccepted || res.ResponseError != nil {
+		t.Fatalf("Send = %+v", res)
+	}
+}
+
+func TestWebhookSendDoesNotFollowRedirects(t *testing.T) {
+	for _, status := range []int{http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther, http.StatusTemporaryRedirect} {
+		followed := false
+		mux := http.NewServeMux()
+		mux.HandleFunc("/sms", func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, "/moved", status)
+		})
+		mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+			followed = true
+			_, _ = w.Write([]byte("<html>moved</html>"))
+		})
+		srv := httptest.NewServer(mux)
+
+		res, err := NewWebhookProvider("primary", srv.URL+"/sms", time.Minute).Send(t.Context(), testMessage)
+		srv.Close()
+
+		var sendErr *SendError
+		if !errors.As(err, &sendErr) {
+			t.Fatalf("%d: Send = %+v, %v, want a send error", status, res, err)
+		}
+		if followed {
+			t.Errorf("%d: redirect was followed", status)
+		}
+		if sendErr.Class != constants.ErrorClassTransient || sendErr.StatusCode != status {
+			t.Errorf("%d: send error = %+v, want a transient error for the redirect", status, sendErr)
+		}
+	}
+}
+
+func TestWebhookSendClassifiesErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error_code": "INVALID_NUMBER"}`))
+	}))
+	defer srv.Close()
+
+	_, err := NewWebhookProvider("primary", srv.URL, time.Minute).Send(t.Context(), testMessage)
+	var sendErr *SendError
+	if !errors.As(err, &sendErr) || sendErr.Class != constants.ErrorClassPermanent || sendErr.Code != "INVALID_NUMBER" {
+		t.Fatalf("Send error = %+v, want a permanent INVALID_NUMBER error", err)
+	}
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"insider-message-sender/internal/constants"
+	"insider-message-sender/internal/model"
+)
+
+type ImportJobRepository struct {
+	db *sql.DB
+}
+
+func NewImportJobRepository(db *sql.DB) *ImportJobRepository {
+	return &ImportJobRepository{db: db}
+}
+
+func (r *ImportJobRepository) Create(ctx context.Context, job *model.ImportJob) error {
+	query := `INSERT INTO import_jobs (filename, format, status, dry_run)
+			  VALUES ($1, $2, $3, $4)
+			  RETURNING id, status, created_at, updated_at`
+
+	return r.db.QueryRowContext(ctx, query, job.Filename, job.Format, constants.ImportStatusQueued, job.DryRun).
+		Scan(&job.ID, &job.Status, &job.CreatedAt, &job.UpdatedAt)
+}
+
+func (r *ImportJobRepository) Get(ctx context.Context, id int64) (*model.ImportJob, error) {
+	query := `SELECT id, filename, format, status, dry_run, total_rows, processed_rows,
+			         accepted_rows, rejected_rows, rejections, COALESCE(error, ''),
+			         created_at, updated_at, completed_at
+			  FROM import_jobs
+			  WHERE id = $1`
+
+	var (
+		job         model.ImportJob
+		rejections  []byte
+		completedAt sql.NullTime
+	)
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&job.ID,
+		&job.Filename,
+		&job.Format,
+		&job.Status,
+		&job.DryRun,
+		&job.TotalRows,
+		&job.ProcessedRows,
+		&job.AcceptedRows,
+		&job.RejectedRows,
+		&rejections,
+		&job.Error,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+		&completedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(rejections, &job.Rejections); err != nil {
+		return nil, err
+	}
+	if completedAt.Valid {
+		job.CompletedAt = &completedAt.Time
+	}
+	return &job, nil
+}
+
+func (r *ImportJobRepository) MarkProcessing(ctx context.Context, id int64, totalRows int) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE import_jobs SET status=$1, total_rows=$2, updated_at=$3 WHERE id=$4`,
+		constants.ImportStatusProcessing, totalRows, time.Now(), id)
+	return err
+}
+
+// AddProgress increments the row counters and appends the given rejections to the job
+func (r *ImportJobRepository) AddProgress(ctx context.Context, id int64, processed, accepted, rejected int, rejections []model.ImportRejection) error {
+	if rejections == nil {
+		rejections = []model.ImportRejection{}
+	}
+	payload, err := json.Marshal(rejections)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE import_jobs
+			  SET processed_rows = processed_rows + $1,
+			      accepted_rows = accepted_rows + $2,
+			      rejected_rows = rejected_rows + $3,
+			      rejections = rejections || $4::jsonb,
+			      updated_at = $5
+			  WHERE id = $6`
+
+	_, err = r.db.ExecContext(ctx, query, processed, accepted, rejected, payload, time.Now(), id)
+	return err
+}
+
+func (r *ImportJobRepository) MarkCompleted(ctx context.Context, id int64) error {
+	now := time.Now()
+	_, err := r.db.ExecContext(ctx, `UPDATE import_jobs SET status=$1, updated_at=$2, completed_at=$2 WHERE id=$3`,
+		constants.ImportStatusCompleted, now, id)
+	return err
+}
+
+func (r *ImportJobRepository) MarkFailed(ctx context.Context, id int64, reason string) error {
+	now := time.Now()
+	_, err := r.db.ExecContext(ctx, `UPDATE import_jobs SET status=$1, error=$2, updated_at=$3, completed_at=$3 WHERE id=$4`,
+		constants.ImportStatusFailed, reason, now, id)
+	return err
+}
@@ -3,21 +3,27 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"insider-message-sender/internal/constants"
 	"insider-message-sender/internal/model"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
+	"github.com/uptrace/opentelemetry-go-extra/otelsql"
 )
 
 type MessageRepository struct {
 	db *sql.DB
 }
 
+// NewMessageRepository opens the connection pool through otelsql, so every query is traced as a child of the
+// span in the context it runs with
 func NewMessageRepository(connStr string) *MessageRepository {
-	db, err := sql.Open("postgres", connStr)
+	db, err := otelsql.Open("postgres", connStr, otelsql.WithDBSystem("postgresql"))
 	if err != nil {
 		log.Fatalf("Failed to open DB connection: %v", err)
 	}
@@ -35,112 +41,823 @@ func setupPool(db *sql.DB) {
 	db.SetConnMaxLifetime(10 * time.Minute)
 }
 
-func (r *MessageRepository) FetchUnsent(limit int) ([]model.Message, error) {
-	rows, err := r.db.Query(`SELECT id, phone_number, content, status FROM messages WHERE status = $1 LIMIT $2`, constants.MessageStatusPending, limit)
+// ErrDuplicateIdempotencyKey is returned when a message with the same idempotency key already exists
+var ErrDuplicateIdempotencyKey = errors.New("duplicate idempotency key")
+
+// ErrLeaseLost is returned by writes on a claimed message that is no longer in progress under the caller's lease,
+// e.g. because the lease was reaped and another replica has picked the message up since
+var ErrLeaseLost = errors.New("message lease no longer held")
+
+func (r *MessageRepository) Create(ctx context.Context, m *model.Message) error {
+	query := `WITH created AS (
+				  INSERT INTO messages (phone_number, content, status, priority, send_at, expires_at, idempotency_key)
+				  VALUES ($1, $2, $3, $4, COALESCE($5::timestamptz, NOW()), $6, $7)
+				  ON CONFLICT (idempotency_key) DO NOTHING
+				  RETURNING id, status, priority, send_at
+			  ), event AS (
+				  INSERT INTO message_events (message_id, type)
+				  SELECT id, $8::message_event_type FROM created
+			  )
+			  SELECT id, status, priority, send_at FROM created`
+
+	err := r.db.QueryRowContext(ctx, query, m.PhoneNumber, m.Content, constants.MessageStatusPending, priorityOrDefault(m.Priority),
+		nullTime(m.SendAt), m.ExpiresAt, nullString(m.IdempotencyKey), constants.MessageEventCreated).
+		Scan(&m.ID, &m.Status, &m.Priority, &m.SendAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrDuplicateIdempotencyKey
+	}
+	return err
+}
+
+// ErrBatchNotStored wraps errors of a batch insert that was rolled back, so none of its messages were stored
+// and they can safely be inserted again one by one
+var ErrBatchNotStored = errors.New("batch insert failed")
+
+// CreateBatch inserts all messages with a single multi-row statement and fills in their ids and status.
+// Messages whose idempotency key already exists are skipped and keep a zero ID.
+// Only errors wrapping ErrBatchNotStored guarantee that nothing was inserted; after any other error the batch
+// may have been stored without the ids being read back.
+// Callers are expected to keep the batch small enough to stay under the Postgres parameter limit.
+func (r *MessageRepository) CreateBatch(ctx context.Context, msgs []model.Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	query, args := batchInsertQuery(msgs)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("%w: %w", ErrBatchNotStored, err)
 	}
 	defer rows.Close() //nolint:errcheck
 
-	var msgs []model.Message
 	for rows.Next() {
-		var m model.Message
-		if err := rows.Scan(&m.ID, &m.PhoneNumber, &m.Content, &m.Status); err != nil {
-			return nil, err
+		var (
+			ord int
+			m   model.Message
+		)
+		if err := rows.Scan(&ord, &m.ID, &m.Status, &m.Priority, &m.SendAt); err != nil {
+			return err
 		}
-		msgs = append(msgs, m)
+		if err := assignBatchRow(msgs, ord, m); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		// An error reported by Postgres aborted the statement, and with it the whole insert
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) {
+			return fmt.Errorf("%w: %w", ErrBatchNotStored, err)
+		}
+		return err
 	}
-	return msgs, nil
+	return nil
 }
 
-func (r *MessageRepository) MarkAsSent(id int64) error {
-	_, err := r.db.Exec(`UPDATE messages SET status=$1, sent_at=$2 WHERE id=$3`, constants.MessageStatusSent, time.Now(), id)
+// batchInsertQuery builds the multi-row insert behind CreateBatch. Postgres does not return the rows of an insert
+// in input order, so every row carries its index in msgs and ids are drawn up front to join the inserted rows
+// back to their index.
+func batchInsertQuery(msgs []model.Message) (string, []any) {
+	var sb strings.Builder
+	sb.WriteString(`WITH input (ord, phone_number, content, priority, send_at, expires_at, idempotency_key) AS (VALUES `)
+	args := make([]any, 0, len(msgs)*6+2)
+	for i, m := range msgs {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		n := len(args)
+		fmt.Fprintf(&sb, "(%d, $%d::text, $%d::text, $%d::message_priority, $%d::timestamptz, $%d::timestamptz, $%d::text)",
+			i, n+1, n+2, n+3, n+4, n+5, n+6)
+		args = append(args, m.PhoneNumber, m.Content, priorityOrDefault(m.Priority), nullTime(m.SendAt), m.ExpiresAt,
+			nullString(m.IdempotencyKey))
+	}
+	args = append(args, constants.MessageStatusPending, constants.MessageEventCreated)
+	fmt.Fprintf(&sb, `), numbered AS (
+		SELECT nextval(pg_get_serial_sequence('messages', 'id')) AS id, input.* FROM input
+	), created AS (
+		INSERT INTO messages (id, phone_number, content, status, priority, send_at, expires_at, idempotency_key)
+		SELECT id, phone_number, content, $%d::message_status, priority, COALESCE(send_at, NOW()), expires_at, idempotency_key
+		FROM numbered
+		ORDER BY ord
+		ON CONFLICT (idempotency_key) DO NOTHING
+		RETURNING id, status, priority, send_at
+	), event AS (
+		INSERT INTO message_events (message_id, type) SELECT id, $%d::message_event_type FROM created
+	)
+	SELECT numbered.ord, created.id, created.status, created.priority, created.send_at
+	FROM created JOIN numbered ON numbered.id = created.id`, len(args)-1, len(args))
+	return sb.String(), args
+}
+
+// assignBatchRow copies a row returned by the batch insert onto the message at index ord
+func assignBatchRow(msgs []model.Message, ord int, m model.Message) error {
+	if ord < 0 || ord >= len(msgs) {
+		return fmt.Errorf("batch insert returned unknown row %d", ord)
+	}
+	msgs[ord].ID, msgs[ord].Status, msgs[ord].Priority, msgs[ord].SendAt = m.ID, m.Status, m.Priority, m.SendAt
+	return nil
+}
+
+// FetchByIdempotencyKeys returns the stored messages with the given idempotency keys, keyed by idempotency key
+func (r *MessageRepository) FetchByIdempotencyKeys(ctx context.Context, keys []string) (map[string]model.Message, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+messageColumns+` FROM messages WHERE idempotency_key = ANY($1::text[])`,
+		pq.StringArray(keys))
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := scanMessages(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[string]model.Message, len(msgs))
+	for _, m := range msgs {
+		byKey[m.IdempotencyKey] = m
+	}
+	return byKey, nil
+}
+
+// ClaimUnsent atomically moves up to limit pending messages to in_progress under the given owner's lease.
+// Rows locked by another replica are skipped, so concurrent schedulers never claim the same message.
+// An empty priority claims from every priority class, most urgent first.
+func (r *MessageRepository) ClaimUnsent(ctx context.Context, owner, priority string, limit int, lease time.Duration) ([]model.Message, error) {
+	query := `WITH claimed AS (
+				  UPDATE messages
+				  SET status = $1, lease_owner = $2, lease_expires_at = NOW() + make_interval(secs => $3)
+				  WHERE id IN (
+				      SELECT id FROM messages
+				      WHERE status = $4 AND send_at <= NOW() AND (expires_at IS NULL OR expires_at > NOW())
+				        AND (next_attempt_at IS NULL OR next_attempt_at <= NOW())
+				        AND ($6::message_priority IS NULL OR priority = $6::message_priority)
+				      ORDER BY priority DESC, send_at, id
+				      LIMIT $5
+				      FOR UPDATE SKIP LOCKED
+				  )
+				  RETURNING ` + messageColumns + `
+			  ), event AS (
+				  INSERT INTO message_events (message_id, type, detail)
+				  SELECT id, $7::message_event_type, 'claimed by ' || $2::text FROM claimed
+			  )
+			  SELECT ` + messageColumns + ` FROM claimed
+			  ORDER BY priority DESC, send_at, id`
+
+	var priorityFilter any
+	if priority != "" {
+		priorityFilter = priority
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, constants.MessageStatusInProgress, owner, lease.Seconds(), constants.MessageStatusPending, limit,
+		priorityFilter, constants.MessageEventClaimed)
+	if err != nil {
+		return nil, err
+	}
+	return scanMessages(rows)
+}
+
+// ReleaseLease returns a message claimed by owner to pending so any replica can pick it up again
+func (r *MessageRepository) ReleaseLease(ctx context.Context, id int64, owner string) error {
+	_, err := r.db.ExecContext(ctx, `WITH released AS (
+							 UPDATE messages SET status=$1, lease_owner=NULL, lease_expires_at=NULL
+							 WHERE id=$2 AND status=$3 AND lease_owner=$4
+							 RETURNING id
+						 )
+						 INSERT INTO message_events (message_id, type, detail)
+						 SELECT id, $5::message_event_type, 'released by ' || $4::text FROM released`,
+		constants.MessageStatusPending, id, constants.MessageStatusInProgress, owner, constants.MessageEventReleased)
 	return err
 }
 
-func (r *MessageRepository) MarkAsFailed(id int64) error {
-	_, err := r.db.Exec(`UPDATE messages SET status=$1, sent_at=$2 WHERE id=$3`, constants.MessageStatusFailed, time.Now(), id)
+// ReleaseExpiredLeases returns in_progress messages whose lease has lapsed to pending, e.g. after a replica crashed
+func (r *MessageRepository) ReleaseExpiredLeases(ctx context.Context) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `WITH expired AS (
+							   SELECT id, lease_owner FROM messages
+							   WHERE status=$2 AND lease_expires_at < NOW()
+							   FOR UPDATE SKIP LOCKED
+						   ), released AS (
+							   UPDATE messages m SET status=$1, lease_owner=NULL, lease_expires_at=NULL
+							   FROM expired
+							   WHERE m.id = expired.id
+							   RETURNING m.id, expired.lease_owner
+						   )
+						   INSERT INTO message_events (message_id, type, detail)
+						   SELECT id, $3::message_event_type, 'lease of ' || lease_owner || ' expired' FROM released`,
+		constants.MessageStatusPending, constants.MessageStatusInProgress, constants.MessageEventReleased)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// MarkAsSent records an accepted send: the status change and the provider's message id are written in one statement.
+// Messages already recorded as sent are left alone, so replaying an outcome never overwrites a delivery report.
+func (r *MessageRepository) MarkAsSent(ctx context.Context, o model.SentOutcome) error {
+	_, err := r.db.ExecContext(ctx, `WITH sent AS (
+							 UPDATE messages
+							 SET status=$1, sent_at=$2, attempt_count=$3, provider=$4, provider_message_id=$5, last_status_code=$6,
+							     last_error=COALESCE($7, last_error), next_attempt_at=NULL, lease_owner=NULL, lease_expires_at=NULL
+							 WHERE id=$8 AND status <> ALL($9::message_status[])
+							 RETURNING id
+						 )
+						 INSERT INTO message_events (message_id, type, attempt, provider, status_code, detail)
+						 SELECT id, $10::message_event_type, $3, $4, $6, CONCAT_WS('; ', 'messageId ' || $5::text, $7::text) FROM sent`,
+		constants.MessageStatusSent, o.SentAt, o.Attempts, o.Provider, nullString(o.ProviderMessageID), nullInt(o.StatusCode),
+		nullString(o.Note), o.MessageID, pq.StringArray(constants.SentStatusValues()), constants.MessageEventSent)
 	return err
 }
 
-func (r *MessageRepository) FetchSent(limit, offset int) ([]model.Message, error) {
-	query := `SELECT id, phone_number, content, status, sent_at 
-			  FROM messages
-			  WHERE status = $1
-			  ORDER BY sent_at DESC
-			  LIMIT $2 OFFSET $3`
+// MarkAsFailed records a final failure of a message claimed by owner
+func (r *MessageRepository) MarkAsFailed(ctx context.Context, id int64, owner string, f model.SendFailure) error {
+	return leaseHeld(r.db.ExecContext(ctx, `WITH failed AS (
+							 UPDATE messages
+							 SET status=$1, failed_at=$2, attempt_count=$3, last_error=$4, error_class=$5, provider_error_code=$6,
+							     provider=COALESCE($7, provider), last_status_code=$8, next_attempt_at=NULL, lease_owner=NULL, lease_expires_at=NULL
+							 WHERE id=$9 AND status=$11 AND lease_owner=$12
+							 RETURNING id
+						 )
+						 INSERT INTO message_events (message_id, type, attempt, provider, status_code, detail)
+						 SELECT id, $10::message_event_type, $3, $7::text, $8, $4 FROM failed`,
+		constants.MessageStatusFailed, time.Now(), f.Attempts, f.Error, nullString(f.ErrorClass), nullString(f.ProviderCode),
+		nullString(f.Provider), nullInt(f.StatusCode), id, constants.MessageEventFailed, constants.MessageStatusInProgress, owner))
+}
+
+// ScheduleRetry records a failed attempt of a message claimed by owner and returns it to pending until nextAttemptAt
+func (r *MessageRepository) ScheduleRetry(ctx context.Context, id int64, owner string, f model.SendFailure, nextAttemptAt time.Time) error {
+	return leaseHeld(r.db.ExecContext(ctx, `WITH retry AS (
+							 UPDATE messages
+							 SET status=$1, attempt_count=$2, last_error=$3, error_class=$4, provider_error_code=$5,
+							     provider=COALESCE($6, provider), last_status_code=$7, next_attempt_at=$8, lease_owner=NULL, lease_expires_at=NULL
+							 WHERE id=$9 AND status=$11 AND lease_owner=$12
+							 RETURNING id
+						 )
+						 INSERT INTO message_events (message_id, type, attempt, provider, status_code, detail)
+						 SELECT id, $10::message_event_type, $2, $6::text, $7, $3 FROM retry`,
+		constants.MessageStatusPending, f.Attempts, f.Error, nullString(f.ErrorClass), nullString(f.ProviderCode),
+		nullString(f.Provider), nullInt(f.StatusCode), nextAttemptAt, id, constants.MessageEventRetryScheduled,
+		constants.MessageStatusInProgress, owner))
+}
+
+// DeferAttempt returns a message claimed by owner to pending until the given time without counting an attempt
+func (r *MessageRepository) DeferAttempt(ctx context.Context, id int64, owner, reason string, until time.Time) error {
+	return leaseHeld(r.db.ExecContext(ctx, `WITH deferred AS (
+							 UPDATE messages
+							 SET status=$1, last_error=$2, next_attempt_at=$3, lease_owner=NULL, lease_expires_at=NULL
+							 WHERE id=$4 AND status=$6 AND lease_owner=$7
+							 RETURNING id
+						 )
+						 INSERT INTO message_events (message_id, type, detail)
+						 SELECT id, $5::message_event_type, $2 FROM deferred`,
+		constants.MessageStatusPending, reason, until, id, constants.MessageEventDeferred, constants.MessageStatusInProgress, owner))
+}
+
+// ExtendLease renews the lease of a message claimed by owner, so a message waiting for its turn is not reaped
+// while it is being sent. A lease that lapsed but was not reaped yet can still be renewed, no other replica
+// can have claimed the message in the meantime.
+func (r *MessageRepository) ExtendLease(ctx context.Context, id int64, owner string, lease time.Duration) error {
+	return leaseHeld(r.db.ExecContext(ctx, `UPDATE messages SET lease_expires_at = NOW() + make_interval(secs => $1)
+						 WHERE id=$2 AND status=$3 AND lease_owner=$4`,
+		lease.Seconds(), id, constants.MessageStatusInProgress, owner))
+}
+
+// SearchMessages returns up to limit messages matching f in the order given by sort, starting after the cursor
+// and skipping the first offset of them
+func (r *MessageRepository) SearchMessages(ctx context.Context, f model.MessageFilter, sort model.MessageSort, after *model.MessageCursor, limit, offset int) ([]model.Message, error) {
+	query, args := messageQuery(f, sort, after)
+	args = append(args, limit)
+	query = fmt.Sprintf("%s LIMIT $%d", query, len(args))
+	if offset > 0 {
+		args = append(args, offset)
+		query = fmt.Sprintf("%s OFFSET $%d", query, len(args))
+	}
 
-	rows, err := r.db.Query(query, constants.MessageStatusSent, limit, offset)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close() //nolint:errcheck
+	return scanMessages(rows)
+}
+
+// CountMessages returns how many messages SearchMessages can list for f and sort across all pages
+func (r *MessageRepository) CountMessages(ctx context.Context, f model.MessageFilter, sort model.MessageSort) (int, error) {
+	query, args := messageCountQuery(f, sort)
+	var count int
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&count)
+	return count, err
+}
+
+// StreamMessages calls fn for every message matching f in the order given by sort. Rows are read as they arrive
+// instead of being loaded up front, so memory stays flat however many messages match.
+func (r *MessageRepository) StreamMessages(ctx context.Context, f model.MessageFilter, sort model.MessageSort, fn func(model.Message) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	query, args := messageQuery(f, sort, nil)
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// Cancel first so that stopping early aborts the query instead of draining the remaining rows
+		cancel()
+		rows.Close() //nolint:errcheck
+	}()
 
-	var msgs []model.Message
 	for rows.Next() {
-		var m model.Message
-		if err := rows.Scan(
-			&m.ID,
-			&m.PhoneNumber,
-			&m.Content,
-			&m.Status,
-			&m.SentAt,
-		); err != nil {
-			return nil, err
+		m, err := scanMessage(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(m); err != nil {
+			return err
 		}
-		msgs = append(msgs, m)
 	}
-
-	return msgs, nil
+	return rows.Err()
 }
 
-func (r *MessageRepository) CountSent() (int, error) {
+// CountSent counts messages a provider accepted, including those with a delivery report
+func (r *MessageRepository) CountSent(ctx context.Context) (int, error) {
 	var total int
-	err := r.db.QueryRow(`SELECT COUNT(*) FROM messages WHERE status = $1`, constants.MessageStatusSent).Scan(&total)
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM messages WHERE status = ANY($1::message_status[])`,
+		pq.StringArray(constants.SentStatusValues())).Scan(&total)
 	return total, err
 }
 
-func (r *MessageRepository) CountFailed() (int, error) {
+func (r *MessageRepository) CountFailed(ctx context.Context) (int, error) {
 	var total int
-	err := r.db.QueryRow(`SELECT COUNT(*) FROM messages WHERE status = $1`, constants.MessageStatusFailed).Scan(&total)
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM messages WHERE status = $1`, constants.MessageStatusFailed).Scan(&total)
 	return total, err
 }
 
-func (r *MessageRepository) CountPending() (int, error) {
+func (r *MessageRepository) CountPending(ctx context.Context) (int, error) {
 	var total int
-	err := r.db.QueryRow(`SELECT COUNT(*) FROM messages WHERE status = $1`, constants.MessageStatusPending).Scan(&total)
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM messages WHERE status = $1`, constants.MessageStatusPending).Scan(&total)
 	return total, err
 }
 
-func (r *MessageRepository) FetchFailed(limit, offset int) ([]model.Message, error) {
-	query := `SELECT id, phone_number, content, status, sent_at 
-			  FROM messages
-			  WHERE status = $1
-			  ORDER BY sent_at DESC
-			  LIMIT $2 OFFSET $3`
+// CountByStatus returns the number of messages in every status, including statuses with no messages
+func (r *MessageRepository) CountByStatus(ctx context.Context) (map[string]int, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT status, COUNT(*) FROM messages GROUP BY status`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //nolint:errcheck
 
-	rows, err := r.db.Query(query, constants.MessageStatusFailed, limit, offset)
+	counts := make(map[string]int)
+	for _, status := range constants.MessageStatusValues() {
+		counts[status] = 0
+	}
+	for rows.Next() {
+		var (
+			status string
+			total  int
+		)
+		if err := rows.Scan(&status, &total); err != nil {
+			return nil, err
+		}
+		counts[status] = total
+	}
+	return counts, rows.Err()
+}
+
+// ExpireStale moves pending messages whose validity period has passed to expired so they are never sent
+func (r *MessageRepository) ExpireStale(ctx context.Context) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `WITH expired AS (
+							   UPDATE messages SET status=$1 WHERE status=$2 AND expires_at <= NOW()
+							   RETURNING id
+						   )
+						   INSERT INTO message_events (message_id, type, detail)
+						   SELECT id, $3::message_event_type, 'expired before it could be sent' FROM expired`,
+		constants.MessageStatusExpired, constants.MessageStatusPending, constants.MessageEventExpired)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// MarkAsExpired moves a message claimed by owner to expired
+func (r *MessageRepository) MarkAsExpired(ctx context.Context, id int64, owner string) error {
+	return leaseHeld(r.db.ExecContext(ctx, `WITH expired AS (
+							 UPDATE messages SET status=$1, lease_owner=NULL, lease_expires_at=NULL
+							 WHERE id=$2 AND status=$4 AND lease_owner=$5
+							 RETURNING id
+						 )
+						 INSERT INTO message_events (message_id, type, detail)
+						 SELECT id, $3::message_event_type, 'expired before it could be sent' FROM expired`,
+		constants.MessageStatusExpired, id, constants.MessageEventExpired, constants.MessageStatusInProgress, owner))
+}
+
+// GetByID returns a single message, or sql.ErrNoRows if it does not exist
+func (r *MessageRepository) GetByID(ctx context.Context, id int64) (*model.Message, error) {
+	m, err := scanMessage(r.db.QueryRowContext(ctx, `SELECT `+messageColumns+` FROM messages WHERE id = $1`, id))
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// GetByProviderMessageID returns the most recent message the provider accepted under messageID,
+// or sql.ErrNoRows if there is none. An empty provider matches any provider.
+func (r *MessageRepository) GetByProviderMessageID(ctx context.Context, provider, messageID string) (*model.Message, error) {
+	m, err := scanMessage(r.db.QueryRowContext(ctx, `SELECT `+messageColumns+`
+										 FROM messages
+										 WHERE provider_message_id = $1 AND ($2::text IS NULL OR provider = $2::text)
+										 ORDER BY id DESC
+										 LIMIT 1`, messageID, nullString(provider)))
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// RequeueFailed sends the failed messages matching f back to pending with a clean attempt state.
+// The previous attempt state of every requeued message is recorded in message_requeues in the same statement.
+func (r *MessageRepository) RequeueFailed(ctx context.Context, f model.RequeueFilter, requestedBy, reason string) ([]int64, error) {
+	var ids any
+	if f.IDs != nil {
+		ids = pq.Int64Array(f.IDs)
+	}
+	var limit any
+	if f.Limit > 0 {
+		limit = f.Limit
+	}
+
+	query := `WITH target AS (
+				  SELECT id, attempt_count, last_error, error_class
+				  FROM messages
+				  WHERE status = $1
+				    AND ($2::bigint[] IS NULL OR id = ANY($2::bigint[]))
+				    AND ($3::timestamptz IS NULL OR failed_at >= $3::timestamptz)
+				    AND ($4::timestamptz IS NULL OR failed_at < $4::timestamptz)
+				    AND ($5::error_class IS NULL OR error_class = $5::error_class)
+				  ORDER BY id
+				  LIMIT $6
+				  FOR UPDATE SKIP LOCKED
+			  ), requeued AS (
+				  UPDATE messages m
+				  SET status = $7, sent_at = NULL, failed_at = NULL, attempt_count = 0, last_error = NULL, next_attempt_at = NULL,
+				      error_class = NULL, provider_error_code = NULL, provider = NULL, provider_message_id = NULL,
+				      last_status_code = NULL, lease_owner = NULL, lease_expires_at = NULL
+				  FROM target
+				  WHERE m.id = target.id
+				  RETURNING m.id, target.attempt_count, target.last_error, target.error_class
+			  ), event AS (
+				  INSERT INTO message_events (message_id, type, detail)
+				  SELECT id, $10::message_event_type, CONCAT_WS(': ', 'requeued by ' || $8::text, $9::text)
+				  FROM requeued
+			  )
+			  INSERT INTO message_requeues (message_id, previous_attempt_count, previous_error, previous_error_class, requested_by, reason)
+			  SELECT id, attempt_count, last_error, error_class, $8, $9
+			  FROM requeued
+			  RETURNING message_id`
+
+	rows, err := r.db.QueryContext(ctx, query,
+		constants.MessageStatusFailed, ids, f.FailedFrom, f.FailedTo, nullString(f.ErrorClass), limit,
+		constants.MessageStatusPending, requestedBy, nullString(reason), constants.MessageEventRequeued)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close() //nolint:errcheck
 
+	requeued := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		requeued = append(requeued, id)
+	}
+	return requeued, rows.Err()
+}
+
+// FetchRequeues returns the requeue audit trail of a message, most recent first
+func (r *MessageRepository) FetchRequeues(ctx context.Context, messageID int64) ([]model.Requeue, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, message_id, previous_attempt_count, COALESCE(previous_error, ''),
+							        COALESCE(previous_error_class::text, ''), requested_by, COALESCE(reason, ''), requeued_at
+							 FROM message_requeues
+							 WHERE message_id = $1
+							 ORDER BY requeued_at DESC, id DESC`, messageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //nolint:errcheck
+
+	requeues := []model.Requeue{}
+	for rows.Next() {
+		var rq model.Requeue
+		if err := rows.Scan(&rq.ID, &rq.MessageID, &rq.PreviousAttemptCount, &rq.PreviousError,
+			&rq.PreviousErrorClass, &rq.RequestedBy, &rq.Reason, &rq.RequeuedAt); err != nil {
+			return nil, err
+		}
+		requeues = append(requeues, rq)
+	}
+	return requeues, rows.Err()
+}
+
+// ApplyDeliveryReport moves a sent message to the reported delivery status. A message already carrying a report
+// only changes for a newer one, so late or repeated receipts are ignored; it returns whether the report was applied.
+func (r *MessageRepository) ApplyDeliveryReport(ctx context.Context, d model.DeliveryReport) (bool, error) {
+	var id int64
+	err := r.db.QueryRowContext(ctx, `WITH reported AS (
+							  UPDATE messages
+							  SET status=$1, delivery_reported_at=$2, delivery_reason=$3
+							  WHERE id=$4 AND (status=$5 OR (status = ANY($6::message_status[]) AND delivery_reported_at < $2))
+							  RETURNING id
+						  )
+						  INSERT INTO message_events (message_id, type, detail)
+						  SELECT id, $7::message_event_type, $3 FROM reported
+						  RETURNING message_id`,
+		d.Status, d.ReportedAt, nullString(d.Reason), d.MessageID, constants.MessageStatusSent,
+		pq.StringArray([]string{constants.MessageStatusDelivered, constants.MessageStatusUndelivered}), d.Status).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// RecordEvent appends an event that does not change the message itself, such as a delivery attempt
+func (r *MessageRepository) RecordEvent(ctx context.Context, e model.MessageEvent) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO message_events (message_id, type, attempt, provider, status_code, latency_ms, detail)
+						 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		e.MessageID, e.Type, nullInt(e.Attempt), nullString(e.Provider), nullInt(e.StatusCode), nullInt(e.LatencyMs), nullString(e.Detail))
+	return err
+}
+
+// FetchEvents returns the timeline of a message, oldest first
+func (r *MessageRepository) FetchEvents(ctx context.Context, messageID int64) ([]model.MessageEvent, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, message_id, type, COALESCE(attempt, 0), COALESCE(provider, ''), COALESCE(status_code, 0),
+							        COALESCE(latency_ms, 0), COALESCE(detail, ''), created_at
+							 FROM message_events
+							 WHERE message_id = $1
+							 ORDER BY created_at, id`, messageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //nolint:errcheck
+
+	events := []model.MessageEvent{}
+	for rows.Next() {
+		var e model.MessageEvent
+		if err := rows.Scan(&e.ID, &e.MessageID, &e.Type, &e.Attempt, &e.Provider, &e.StatusCode,
+			&e.LatencyMs, &e.Detail, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+type sortColumn struct {
+	column   string
+	nullable bool
+}
+
+// sortColumns maps sort fields to their column and whether the column can be NULL
+var sortColumns = map[string]sortColumn{
+	constants.MessageSortID:        {"id", false},
+	constants.MessageSortCreatedAt: {"created_at", false},
+	constants.MessageSortSendAt:    {"send_at", false},
+	constants.MessageSortSentAt:    {"sent_at", true},
+	constants.MessageSortFailedAt:  {"failed_at", true},
+	constants.MessageSortExpiresAt: {"expires_at", true},
+}
+
+// likeEscaper escapes the LIKE wildcards of a user-supplied substring
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// messageQuery builds the keyset query behind message listings and exports. Sorting by a nullable column leaves out
+// messages where it is NULL, so that every listed message has a position to resume from.
+func messageQuery(f model.MessageFilter, sort model.MessageSort, after *model.MessageCursor) (string, []any) {
+	var args queryArgs
+	conds := messageConditions(f, &args)
+
+	col := sortColumnFor(sort)
+	if col.nullable {
+		conds = append(conds, col.column+" IS NOT NULL")
+	}
+
+	dir, cmp := "ASC", ">"
+	if sort.Desc {
+		dir, cmp = "DESC", "<"
+	}
+	if after != nil {
+		if col.column == "id" || after.Value == nil {
+			conds = append(conds, "id "+cmp+" "+args.add(after.ID)+"::bigint")
+		} else {
+			conds = append(conds, fmt.Sprintf("(%s, id) %s (%s::timestamptz, %s::bigint)", col.column, cmp, args.add(*after.Value), args.add(after.ID)))
+		}
+	}
+
+	query := `SELECT ` + messageColumns + ` FROM messages`
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	if col.column == "id" {
+		query += " ORDER BY id " + dir
+	} else {
+		query += fmt.Sprintf(" ORDER BY %s %s, id %s", col.column, dir, dir)
+	}
+	return query, args
+}
+
+// messageCountQuery builds the query counting the messages messageQuery lists for f and sort
+func messageCountQuery(f model.MessageFilter, sort model.MessageSort) (string, []any) {
+	var args queryArgs
+	conds := messageConditions(f, &args)
+	if col := sortColumnFor(sort); col.nullable {
+		conds = append(conds, col.column+" IS NOT NULL")
+	}
+
+	query := `SELECT COUNT(*) FROM messages`
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	return query, args
+}
+
+func sortColumnFor(sort model.MessageSort) sortColumn {
+	col, ok := sortColumns[sort.Field]
+	if !ok {
+		col = sortColumns[constants.MessageSortID]
+	}
+	return col
+}
+
+// queryArgs collects the arguments of a query, handing out their placeholders
+type queryArgs []any
+
+func (a *queryArgs) add(v any) string {
+	*a = append(*a, v)
+	return fmt.Sprintf("$%d", len(*a))
+}
+
+// messageConditions translates f into WHERE conditions, binding their values to args
+func messageConditions(f model.MessageFilter, args *queryArgs) []string {
+	var conds []string
+	if len(f.Statuses) > 0 {
+		conds = append(conds, "status = ANY("+args.add(pq.StringArray(f.Statuses))+"::message_status[])")
+	}
+	if f.PhoneNumber != "" {
+		conds = append(conds, "phone_number = "+args.add(f.PhoneNumber))
+	}
+	if f.Content != "" {
+		conds = append(conds, "content ILIKE "+args.add("%"+likeEscaper.Replace(f.Content)+"%"))
+	}
+	if f.CreatedFrom != nil {
+		conds = append(conds, "created_at >= "+args.add(*f.CreatedFrom))
+	}
+	if f.CreatedTo != nil {
+		conds = append(conds, "created_at < "+args.add(*f.CreatedTo))
+	}
+	if f.SentFrom != nil {
+		conds = append(conds, "sent_at >= "+args.add(*f.SentFrom))
+	}
+	if f.SentTo != nil {
+		conds = append(conds, "sent_at < "+args.add(*f.SentTo))
+	}
+	if f.Provider != "" {
+		conds = append(conds, "provider = "+args.add(f.Provider))
+	}
+	return conds
+}
+
+// messageColumns lists the columns read by scanMessage, in order
+const messageColumns = `id, phone_number, content, status, priority, sent_at, failed_at, send_at, expires_at, created_at,
+	attempt_count, last_error, next_attempt_at, error_class, provider_error_code, provider, provider_message_id,
+	last_status_code, delivery_reported_at, delivery_reason, idempotency_key`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanMessage(row rowScanner) (model.Message, error) {
+	var (
+		m             model.Message
+		sentAt        sql.NullTime
+		failedAt      sql.NullTime
+		expiresAt     sql.NullTime
+		lastError     sql.NullString
+		nextAttemptAt sql.NullTime
+		errorClass    sql.NullString
+		providerCode  sql.NullString
+		provider      sql.NullString
+		providerMsgID sql.NullString
+		statusCode    sql.NullInt64
+		reportedAt    sql.NullTime
+		reason        sql.NullString
+		idemKey       sql.NullString
+	)
+	if err := row.Scan(
+		&m.ID,
+		&m.PhoneNumber,
+		&m.Content,
+		&m.Status,
+		&m.Priority,
+		&sentAt,
+		&failedAt,
+		&m.SendAt,
+		&expiresAt,
+		&m.CreatedAt,
+		&m.AttemptCount,
+		&lastError,
+		&nextAttemptAt,
+		&errorClass,
+		&providerCode,
+		&provider,
+		&providerMsgID,
+		&statusCode,
+		&reportedAt,
+		&reason,
+		&idemKey,
+	); err != nil {
+		return m, err
+	}
+	if sentAt.Valid {
+		m.SentAt = &sentAt.Time
+	}
+	if failedAt.Valid {
+		m.FailedAt = &failedAt.Time
+	}
+	if expiresAt.Valid {
+		m.ExpiresAt = &expiresAt.Time
+	}
+	if nextAttemptAt.Valid {
+		m.NextAttemptAt = &nextAttemptAt.Time
+	}
+	m.LastError = lastError.String
+	m.ErrorClass = errorClass.String
+	m.ProviderErrorCode = providerCode.String
+	m.Provider = provider.String
+	m.ProviderMessageID = providerMsgID.String
+	m.LastStatusCode = int(statusCode.Int64)
+	if reportedAt.Valid {
+		m.DeliveryReportedAt = &reportedAt.Time
+	}
+	m.DeliveryReason = reason.String
+	m.IdempotencyKey = idemKey.String
+	return m, nil
+}
+
+func scanMessages(rows *sql.Rows) ([]model.Message, error) {
+	defer rows.Close() //nolint:errcheck
+
 	var msgs []model.Message
 	for rows.Next() {
-		var m model.Message
-		if err := rows.Scan(
-			&m.ID,
-			&m.PhoneNumber,
-			&m.Content,
-			&m.Status,
-			&m.SentAt,
-		); err != nil {
+		m, err := scanMessage(rows)
+		if err != nil {
 			return nil, err
 		}
 		msgs = append(msgs, m)
 	}
+	return msgs, rows.Err()
+}
+
+func priorityOrDefault(priority string) string {
+	if priority == "" {
+		return constants.MessagePriorityNormal
+	}
+	return priority
+}
+
+// nullString maps the empty string to NULL
+func nullString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// nullInt maps zero to NULL
+func nullInt(n int) any {
+	if n == 0 {
+		return nil
+	}
+	return n
+}
+
+// nullTime maps the zero time to NULL so the column default applies
+func nullTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+// leaseHeld turns the result of a write guarded by a lease into ErrLeaseLost when no row matched
+func leaseHeld(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
 
-	return msgs, nil
+// DB exposes the underlying connection pool so other repositories can share it
+func (r *MessageRepository) DB() *sql.DB {
+	return r.db
 }
 
 func (r *MessageRepository) Close() error {
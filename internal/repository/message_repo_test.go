@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"insider-message-sender/internal/constants"
+	"insider-message-sender/internal/model"
+)
+
+func TestBatchInsertQueryNumbersRowsInInputOrder(t *testing.T) {
+	sendAt := time.Date(2025, 10, 19, 8, 0, 0, 0, time.UTC)
+	expiresAt := sendAt.Add(time.Hour)
+	msgs := []model.Message{
+		{PhoneNumber: "+905551234567", Content: "first"},
+		{PhoneNumber: "+905551234568", Content: "second", Priority: constants.MessagePriorityHigh, SendAt: sendAt,
+			ExpiresAt: &expiresAt, IdempotencyKey: "key:1"},
+	}
+
+	query, args := batchInsertQuery(msgs)
+
+	for _, row := range []string{
+		"(0, $1::text, $2::text, $3::message_priority, $4::timestamptz, $5::timestamptz, $6::text)",
+		"(1, $7::text, $8::text, $9::message_priority, $10::timestamptz, $11::timestamptz, $12::text)",
+	} {
+		if !strings.Contains(query, row) {
+			t.Errorf("query does not contain row %s:\n%s", row, query)
+		}
+	}
+	for _, want := range []string{"$13::message_status", "$14::message_event_type", "JOIN numbered ON numbered.id = created.id"} {
+		if !strings.Contains(query, want) {
+			t.Errorf("query does not contain %s:\n%s", want, query)
+		}
+	}
+
+	if len(args) != 14 {
+		t.Fatalf("got %d args, want 14", len(args))
+	}
+	// Defaults are left to the database: normal priority, NOW() for send_at and no idempotency key
+	if args[2] != constants.MessagePriorityNormal || args[3] != nil || args[5] != nil {
+		t.Errorf("defaults of the first row = %v, %v, %v", args[2], args[3], args[5])
+	}
+	if args[6] != "+905551234568" || args[8] != constants.MessagePriorityHigh || args[9] != sendAt ||
+		args[10] != &expiresAt || args[11] != "key:1" {
+		t.Errorf("args of the second row = %v", args[6:12])
+	}
+	if args[12] != constants.MessageStatusPending || args[13] != constants.MessageEventCreated {
+		t.Errorf("trailing args = %v", args[12:])
+	}
+}
+
+func TestAssignBatchRowMatchesByOrdinal(t *testing.T) {
+	msgs := []model.Message{{Content: "first"}, {Content: "second"}, {Content: "third"}}
+	sendAt := time.Date(2025, 10, 19, 8, 0, 0, 0, time.UTC)
+
+	// Rows come back in any order; each one lands on the message it was inserted for
+	for _, row := range []struct {
+		ord int
+		id  int64
+	}{{2, 12}, {0, 10}} {
+		err := assignBatchRow(msgs, row.ord, model.Message{
+			ID: row.id, Status: constants.MessageStatusPending, Priority: constants.MessagePriorityNormal, SendAt: sendAt,
+		})
+		if err != nil {
+			t.Fatalf("assignBatchRow(%d) = %v", row.ord, err)
+		}
+	}
+
+	if msgs[0].ID != 10 || msgs[2].ID != 12 {
+		t.Errorf("ids = %d, %d, %d, want 10, 0, 12", msgs[0].ID, msgs[1].ID, msgs[2].ID)
+	}
+	// A skipped duplicate keeps a zero ID
+	if msgs[1].ID != 0 || msgs[1].Status != "" {
+		t.Errorf("skipped message was assigned %+v", msgs[1])
+	}
+	if msgs[0].Content != "first" || msgs[2].Status != constants.MessageStatusPending || !msgs[2].SendAt.Equal(sendAt) {
+		t.Errorf("assigned messages = %+v", msgs)
+	}
+}
+
+func TestAssignBatchRowRejectsUnknownOrdinal(t *testing.T) {
+	msgs := make([]model.Message, 2)
+	for _, ord := range []int{-1, 2} {
+		if err := assignBatchRow(msgs, ord, model.Message{ID: 1}); err == nil {
+			t.Errorf("assignBatchRow(%d) = nil, want an error", ord)
+		}
+	}
+}
+
+func TestMessageQueryWithoutFilters(t *testing.T) {
+	query, args := messageQuery(model.MessageFilter{}, model.MessageSort{Field: constants.MessageSortID}, nil)
+	if want := `SELECT ` + messageColumns + ` FROM messages ORDER BY id ASC`; query != want {
+		t.Errorf("query = %s, want %s", query, want)
+	}
+	if len(args) != 0 {
+		t.Errorf("args = %v, want none", args)
+	}
+}
+
+func TestMessageQueryBindsFiltersInOrder(t *testing.T) {
+	from := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)
+	f := model.MessageFilter{
+		Statuses:    []string{constants.MessageStatusSent, constants.MessageStatusDelivered},
+		PhoneNumber: "+905551234567",
+		Content:     `50%_off\`,
+		CreatedFrom: &from,
+		Provider:    "primary",
+	}
+
+	query, args := messageQuery(f, model.MessageSort{Field: constants.MessageSortCreatedAt, Desc: true}, nil)
+
+	want := `SELECT ` + messageColumns + ` FROM messages WHERE status = ANY($1::message_status[]) AND phone_number = $2` +
+		` AND content ILIKE $3 AND created_at >= $4 AND provider = $5 ORDER BY created_at DESC, id DESC`
+	if query != want {
+		t.Errorf("query =\n%s\nwant\n%s", query, want)
+	}
+	if len(args) != 5 || args[2] != `%50\%\_off\\%` || args[4] != "primary" {
+		t.Errorf("args = %v", args)
+	}
+}
+
+func TestMessageQueryResumesAfterCursor(t *testing.T) {
+	at := time.Date(2025, 10, 19, 7, 39, 12, 0, time.UTC)
+	tests := []struct {
+		name  string
+		sort  model.MessageSort
+		after model.MessageCursor
+		want  string
+	}{
+		{
+			name:  "descending nullable column",
+			sort:  model.MessageSort{Field: constants.MessageSortSentAt, Desc: true},
+			after: model.MessageCursor{Value: &at, ID: 42},
+			want: ` WHERE status = ANY($1::message_status[]) AND sent_at IS NOT NULL` +
+				` AND (sent_at, id) < ($2::timestamptz, $3::bigint) ORDER BY sent_at DESC, id DESC`,
+		},
+		{
+			name:  "ascending column",
+			sort:  model.MessageSort{Field: constants.MessageSortSendAt},
+			after: model.MessageCursor{Value: &at, ID: 42},
+			want: ` WHERE status = ANY($1::message_status[]) AND (send_at, id) > ($2::timestamptz, $3::bigint)` +
+				` ORDER BY send_at ASC, id ASC`,
+		},
+		{
+			name:  "id",
+			sort:  model.MessageSort{Field: constants.MessageSortID, Desc: true},
+			after: model.MessageCursor{ID: 42},
+			want:  ` WHERE status = ANY($1::message_status[]) AND id < $2::bigint ORDER BY id DESC`,
+		},
+	}
+	for _, tt := range tests {
+		f := model.MessageFilter{Statuses: []string{constants.MessageStatusSent}}
+		query, _ := messageQuery(f, tt.sort, &tt.after)
+		if want := `SELECT ` + messageColumns + ` FROM messages` + tt.want; query != want {
+			t.Errorf("%s: query =\n%s\nwant\n%s", tt.name, query, want)
+		}
+	}
+}
+
+func TestMessageCountQueryMatchesListedMessages(t *testing.T) {
+	f := model.MessageFilter{Statuses: []string{constants.MessageStatusFailed}, Provider: "backup"}
+	query, args := messageCountQuery(f, model.MessageSort{Field: constants.MessageSortFailedAt, Desc: true})
+
+	want := `SELECT COUNT(*) FROM messages WHERE status = ANY($1::message_status[]) AND provider = $2 AND failed_at IS NOT NULL`
+	if query != want {
+		t.Errorf("query =\n%s\nwant\n%s", query, want)
+	}
+	if len(args) != 2 {
+		t.Errorf("args = %v, want 2", args)
+	}
+}
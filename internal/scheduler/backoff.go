@@ -0,0 +1,41 @@
+package scheduler
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// BackoffPolicy computes how long to wait before retrying a failed send
+type BackoffPolicy struct {
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Jitter randomizes each delay by up to this fraction in either direction (0 disables it)
+	Jitter float64
+}
+
+// Delay returns the wait before the next attempt after the given number of failed attempts (1-based):
+// BaseDelay doubled per attempt, capped at MaxDelay, then jittered so retries from one batch spread out
+func (p BackoffPolicy) Delay(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	delay := float64(p.BaseDelay) * math.Pow(2, float64(attempts-1))
+	if delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		delay *= 1 + p.Jitter*(2*rand.Float64()-1)
+	}
+	if delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+	return time.Duration(delay)
+}
+
+// RetryDelay is Delay, extended to the wait the provider asked for in Retry-After. The request is honored
+// only up to MaxDelay, so a bogus Retry-After cannot park a message for days.
+func (p BackoffPolicy) RetryDelay(attempts int, retryAfter time.Duration) time.Duration {
+	return min(max(p.Delay(attempts), retryAfter), p.MaxDelay)
+}
@@ -0,0 +1,70 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDelayDoublesPerAttemptUpToMax(t *testing.T) {
+	p := BackoffPolicy{BaseDelay: 30 * time.Second, MaxDelay: 5 * time.Minute}
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{4, 4 * time.Minute},
+		{5, 5 * time.Minute},
+		{60, 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := p.Delay(tt.attempts); got != tt.want {
+			t.Errorf("Delay(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestDelayJitterStaysWithinBounds(t *testing.T) {
+	p := BackoffPolicy{BaseDelay: time.Minute, MaxDelay: time.Hour, Jitter: 0.2}
+	low, high := 48*time.Second, 72*time.Second
+	seen := make(map[time.Duration]bool)
+	for i := 0; i < 1000; i++ {
+		d := p.Delay(1)
+		if d < low || d > high {
+			t.Fatalf("Delay(1) = %s, want between %s and %s", d, low, high)
+		}
+		seen[d] = true
+	}
+	if len(seen) < 2 {
+		t.Fatal("jitter produced the same delay every time")
+	}
+}
+
+func TestDelayJitterNeverExceedsMax(t *testing.T) {
+	p := BackoffPolicy{BaseDelay: time.Minute, MaxDelay: 10 * time.Minute, Jitter: 0.5}
+	for i := 0; i < 1000; i++ {
+		if d := p.Delay(10); d > p.MaxDelay {
+			t.Fatalf("Delay(10) = %s, want at most %s", d, p.MaxDelay)
+		}
+	}
+}
+
+func TestRetryDelayHonorsRetryAfterUpToMax(t *testing.T) {
+	p := BackoffPolicy{BaseDelay: 30 * time.Second, MaxDelay: 30 * time.Minute}
+	tests := []struct {
+		retryAfter time.Duration
+		want       time.Duration
+	}{
+		{0, 30 * time.Second},
+		{10 * time.Second, 30 * time.Second},
+		{5 * time.Minute, 5 * time.Minute},
+		{999999 * time.Second, 30 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := p.RetryDelay(1, tt.retryAfter); got != tt.want {
+			t.Errorf("RetryDelay(1, %s) = %s, want %s", tt.retryAfter, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"insider-message-sender/internal/cache"
+)
+
+const leaderKey = "insider:scheduler:leader"
+
+// LeaderElector holds a Redis lease so that only one replica runs the scheduler loop at a time.
+// Standby replicas keep trying to acquire the lease and take over once the leader stops renewing it.
+type LeaderElector struct {
+	cache    *cache.RedisClient
+	id       string
+	ttl      time.Duration
+	mu       sync.RWMutex
+	isLeader bool
+}
+
+func NewLeaderElector(cache *cache.RedisClient, id string, ttl time.Duration) *LeaderElector {
+	return &LeaderElector{
+		cache: cache,
+		id:    id,
+		ttl:   ttl,
+	}
+}
+
+// renewInterval leaves room for two missed renewals before the lease lapses
+func (e *LeaderElector) renewInterval() time.Duration {
+	return e.ttl / 3
+}
+
+// Acquire blocks until this replica becomes leader, returning false if ctx is cancelled first
+func (e *LeaderElector) Acquire(ctx context.Context) bool {
+	ticker := time.NewTicker(e.renewInterval())
+	defer ticker.Stop()
+
+	for {
+		ok, err := e.cache.AcquireLock(ctx, leaderKey, e.id, e.ttl)
+		if err != nil {
+			log.Printf("Leader election error: %v", err)
+		}
+		if ok {
+			e.setLeader(true)
+			log.Printf("Acquired scheduler leadership as %s", e.id)
+			return true
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// KeepAlive renews the lease until ctx is cancelled or leadership is lost
+func (e *LeaderElector) KeepAlive(ctx context.Context) {
+	ticker := time.NewTicker(e.renewInterval())
+	defer ticker.Stop()
+
+	lastRenewed := time.Now()
+	for {
+		select {
+		case <-ticker.C:
+			ok, err := e.cache.RenewLock(ctx, leaderKey, e.id, e.ttl)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				// A transient Redis error is tolerated as long as the lease has not lapsed yet
+				log.Printf("Failed to renew scheduler leadership: %v", err)
+				if time.Since(lastRenewed)+e.renewInterval() < e.ttl {
+					continue
+				}
+				ok = false
+			} else {
+				lastRenewed = time.Now()
+			}
+			if !ok {
+				e.setLeader(false)
+				log.Printf("Lost scheduler leadership as %s", e.id)
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Release gives up leadership so a standby replica can take over without waiting for the lease to expire
+func (e *LeaderElector) Release() {
+	e.setLeader(false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := e.cache.ReleaseLock(ctx, leaderKey, e.id); err != nil {
+		log.Printf("Failed to release scheduler leadership: %v", err)
+	}
+}
+
+func (e *LeaderElector) IsLeader() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.isLeader
+}
+
+// CurrentLeader returns the identity of the replica currently holding the lease, or an empty string if none does
+func (e *LeaderElector) CurrentLeader(ctx context.Context) (string, error) {
+	return e.cache.Get(ctx, leaderKey)
+}
+
+func (e *LeaderElector) setLeader(v bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.isLeader = v
+}
@@ -0,0 +1,69 @@
+package scheduler
+
+import (
+	"math"
+	"sort"
+
+	"insider-message-sender/internal/constants"
+)
+
+// priorityAllocator splits each tick's batch between priority classes in proportion to their shares.
+// Fractional entitlements carry over between ticks, so a class whose share is worth less than one
+// message per tick still gets a slot every few ticks instead of being starved by a large backlog.
+type priorityAllocator struct {
+	shares  map[string]int
+	credits map[string]float64
+}
+
+func newPriorityAllocator(shares map[string]int) *priorityAllocator {
+	return &priorityAllocator{
+		shares:  shares,
+		credits: make(map[string]float64),
+	}
+}
+
+// allocate returns how many messages of each priority class may be claimed this tick
+func (a *priorityAllocator) allocate(batch int) map[string]int {
+	total := 0
+	for _, share := range a.shares {
+		total += share
+	}
+
+	quotas := make(map[string]int)
+	if total == 0 {
+		return quotas
+	}
+
+	assigned := 0
+	classes := constants.MessagePriorityValues()
+	for _, class := range classes {
+		a.credits[class] += float64(batch) * float64(a.shares[class]) / float64(total)
+		quotas[class] = int(math.Max(0, math.Floor(a.credits[class])))
+		assigned += quotas[class]
+	}
+
+	// Hand out the slots lost to rounding to the classes that are owed the most, most urgent first on ties
+	sort.SliceStable(classes, func(i, j int) bool {
+		return a.credits[classes[i]]-float64(quotas[classes[i]]) > a.credits[classes[j]]-float64(quotas[classes[j]])
+	})
+	for i := 0; assigned < batch; i = (i + 1) % len(classes) {
+		if a.shares[classes[i]] == 0 {
+			continue
+		}
+		quotas[classes[i]]++
+		assigned++
+	}
+
+	for class, quota := range quotas {
+		a.credits[class] -= float64(quota)
+	}
+	return quotas
+}
+
+// settle forgets the entitlement of a class that had fewer messages waiting than it was allowed,
+// so an idle class cannot bank credit and burst later
+func (a *priorityAllocator) settle(class string, quota, claimed int) {
+	if claimed < quota {
+		a.credits[class] = 0
+	}
+}
@@ -0,0 +1,93 @@
+package scheduler
+
+import (
+	"testing"
+
+	"insider-message-sender/internal/constants"
+)
+
+var defaultShares = map[string]int{
+	constants.MessagePriorityHigh:   70,
+	constants.MessagePriorityNormal: 25,
+	constants.MessagePriorityLow:    5,
+}
+
+func sumQuotas(quotas map[string]int) int {
+	n := 0
+	for _, q := range quotas {
+		n += q
+	}
+	return n
+}
+
+func TestAllocateFillsEveryTickAndFollowsSharesOverTime(t *testing.T) {
+	a := newPriorityAllocator(defaultShares)
+	totals := make(map[string]int)
+	for tick := 0; tick < 100; tick++ {
+		quotas := a.allocate(10)
+		if n := sumQuotas(quotas); n != 10 {
+			t.Fatalf("tick %d: allocated %d slots, want 10", tick, n)
+		}
+		for class, q := range quotas {
+			totals[class] += q
+		}
+	}
+
+	for class, share := range defaultShares {
+		want := share * 10
+		if diff := totals[class] - want; diff < -1 || diff > 1 {
+			t.Errorf("%s got %d slots over 100 ticks, want %d", class, totals[class], want)
+		}
+	}
+}
+
+func TestAllocateCarriesFractionalSharesOver(t *testing.T) {
+	a := newPriorityAllocator(defaultShares)
+	got := false
+	// A 5% share of a one-message batch is owed a slot every 20 ticks
+	for tick := 0; tick < 20; tick++ {
+		if a.allocate(1)[constants.MessagePriorityLow] > 0 {
+			got = true
+		}
+	}
+	if !got {
+		t.Fatal("low priority got no slot in 20 ticks of batch 1")
+	}
+}
+
+func TestAllocateSkipsClassesWithoutShare(t *testing.T) {
+	a := newPriorityAllocator(map[string]int{constants.MessagePriorityHigh: 1, constants.MessagePriorityLow: 0})
+	for tick := 0; tick < 10; tick++ {
+		quotas := a.allocate(3)
+		if quotas[constants.MessagePriorityHigh] != 3 || quotas[constants.MessagePriorityNormal] != 0 ||
+			quotas[constants.MessagePriorityLow] != 0 {
+			t.Fatalf("tick %d: quotas = %v, want all 3 slots for high", tick, quotas)
+		}
+	}
+}
+
+func TestAllocateWithoutSharesAllocatesNothing(t *testing.T) {
+	a := newPriorityAllocator(map[string]int{})
+	if quotas := a.allocate(10); sumQuotas(quotas) != 0 {
+		t.Fatalf("quotas = %v, want none", quotas)
+	}
+}
+
+func TestSettleForgetsCreditOnlyWhenClassRanShort(t *testing.T) {
+	a := newPriorityAllocator(defaultShares)
+	a.allocate(3)
+	normal, low := a.credits[constants.MessagePriorityNormal], a.credits[constants.MessagePriorityLow]
+	if low == 0 {
+		t.Fatal("expected low priority to carry a fractional credit")
+	}
+
+	a.settle(constants.MessagePriorityNormal, 1, 1)
+	a.settle(constants.MessagePriorityLow, 1, 0)
+
+	if a.credits[constants.MessagePriorityNormal] != normal {
+		t.Errorf("normal credit changed from %v to %v although it used its quota", normal, a.credits[constants.MessagePriorityNormal])
+	}
+	if a.credits[constants.MessagePriorityLow] != 0 {
+		t.Errorf("low credit = %v after running short, want 0", a.credits[constants.MessagePriorityLow])
+	}
+}
@@ -1,49 +1,78 @@
 package scheduler
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
 	"sync"
 	"time"
 
 	"insider-message-sender/internal/cache"
 	"insider-message-sender/internal/config"
+	"insider-message-sender/internal/constants"
+	"insider-message-sender/internal/metrics"
 	"insider-message-sender/internal/model"
+	"insider-message-sender/internal/provider"
 	"insider-message-sender/internal/repository"
+	"insider-message-sender/internal/validator"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("insider-message-sender/internal/scheduler")
+
 type Scheduler struct {
-	cfg       *config.Config
-	repo      *repository.MessageRepository
-	cache     *cache.RedisClient
-	client    *http.Client
-	isRunning bool
-	ctx       context.Context
-	cancel    context.CancelFunc
-	mu        sync.Mutex
+	cfg      *config.Config
+	repo     *repository.MessageRepository
+	cache    *cache.RedisClient
+	router   *provider.Router
+	elector  *LeaderElector
+	settings *settingsStore
+	// priorities is only used from the sending loop, which runs on a single goroutine
+	priorities *priorityAllocator
+	backoff    BackoffPolicy
+	isRunning  bool
+	cancel     context.CancelFunc
+	// done is closed once the current start cycle has finished all of its work
+	done chan struct{}
+	mu   sync.Mutex
 }
 
-func NewScheduler(cfg *config.Config, repo *repository.MessageRepository, cache *cache.RedisClient) *Scheduler {
+// NewScheduler returns a stopped scheduler, or an error if the configured batch size, concurrency or send
+// interval is outside the range the settings API accepts
+func NewScheduler(cfg *config.Config, repo *repository.MessageRepository, cache *cache.RedisClient, router *provider.Router) (*Scheduler, error) {
+	settings := Settings{
+		BatchSize:      cfg.BatchSize,
+		MaxConcurrency: cfg.MaxConcurrency,
+		SendInterval:   cfg.SendInterval,
+	}
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+
+	var elector *LeaderElector
+	if cfg.LeaderElection {
+		elector = NewLeaderElector(cache, cfg.InstanceID, cfg.LeaderLeaseTTL)
+	}
+
 	return &Scheduler{
-		cfg:   cfg,
-		repo:  repo,
-		cache: cache,
-		client: &http.Client{
-			Timeout: 10 * time.Second,
-			Transport: &http.Transport{
-				TLSHandshakeTimeout:   5 * time.Second,
-				ResponseHeaderTimeout: 5 * time.Second,
-				MaxIdleConns:          10,
-				MaxIdleConnsPerHost:   2,
-				IdleConnTimeout:       cfg.SendInterval + 30*time.Second,
-			},
+		cfg:        cfg,
+		repo:       repo,
+		cache:      cache,
+		router:     router,
+		elector:    elector,
+		settings:   newSettingsStore(settings),
+		priorities: newPriorityAllocator(cfg.PriorityShares),
+		backoff: BackoffPolicy{
+			BaseDelay: cfg.RetryBaseDelay,
+			MaxDelay:  cfg.RetryMaxDelay,
+			Jitter:    cfg.RetryJitter,
 		},
-	}
+	}, nil
 }
 
 func (s *Scheduler) Start() error {
@@ -54,27 +83,38 @@ func (s *Scheduler) Start() error {
 	}
 
 	// Create new context for this start cycle
-	s.ctx, s.cancel = context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	s.cancel = cancel
+	s.done = done
 	s.isRunning = true
 	s.mu.Unlock()
 
 	log.Println("Scheduler started...")
-	go s.run()
+	go func() {
+		defer close(done)
+		s.run(ctx)
+	}()
 
 	return nil
 }
 
+// Stop cancels the running cycle and waits until it has wound down: sends already handed to a provider have
+// recorded their outcome and leadership is released. Only then is it safe to close Redis and the database.
 func (s *Scheduler) Stop() error {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	if !s.isRunning {
+		s.mu.Unlock()
 		return nil
 	}
 
 	s.cancel() // Cancel context to stop all ongoing operations
 	s.isRunning = false
+	done := s.done
+	s.mu.Unlock()
 
+	log.Println("Stopping scheduler, waiting for in-flight sends...")
+	<-done
 	log.Println("Scheduler stopped!!!")
 	return nil
 }
@@ -85,146 +125,548 @@ func (s *Scheduler) IsRunning() bool {
 	return s.isRunning
 }
 
-func (s *Scheduler) run() {
-	s.process()
+// IsLeader reports whether this replica is currently allowed to send.
+// Without leader election every running replica is active.
+func (s *Scheduler) IsLeader() bool {
+	if s.elector == nil {
+		return s.IsRunning()
+	}
+	return s.elector.IsLeader()
+}
+
+// Leader returns the identity of the replica currently sending messages, or an empty string if there is none
+func (s *Scheduler) Leader(ctx context.Context) (string, error) {
+	if s.elector == nil {
+		if s.IsRunning() {
+			return s.cfg.InstanceID, nil
+		}
+		return "", nil
+	}
+	return s.elector.CurrentLeader(ctx)
+}
+
+// Circuits returns the circuit breaker state of every provider
+func (s *Scheduler) Circuits() []provider.ProviderCircuit {
+	return s.router.Circuits()
+}
+
+func (s *Scheduler) InstanceID() string {
+	return s.cfg.InstanceID
+}
+
+// run drives one start cycle until ctx is cancelled. It is given the context of its own cycle, since a
+// Stop followed by a Start replaces the scheduler's cancel func while the previous run may still be returning.
+func (s *Scheduler) run(ctx context.Context) {
+	if s.elector == nil {
+		s.lead(ctx)
+		return
+	}
 
-	ticker := time.NewTicker(s.cfg.SendInterval)
+	for {
+		log.Printf("Waiting for scheduler leadership as %s...", s.cfg.InstanceID)
+		if !s.elector.Acquire(ctx) {
+			return
+		}
+
+		// Stop leading as soon as the lease is lost, even if the scheduler itself keeps running
+		leaderCtx, cancel := context.WithCancel(ctx)
+		keepAliveDone := make(chan struct{})
+		go func() {
+			defer close(keepAliveDone)
+			s.elector.KeepAlive(leaderCtx)
+			cancel()
+		}()
+		s.lead(leaderCtx)
+		cancel()
+		<-keepAliveDone
+
+		if ctx.Err() != nil {
+			s.elector.Release()
+			return
+		}
+	}
+}
+
+// lead runs the sending loop until ctx is cancelled. It returns once the current batch has been processed and
+// the background loops have exited.
+func (s *Scheduler) lead(ctx context.Context) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		s.reapLeases(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		s.reconcile(ctx)
+	}()
+
+	s.refreshSettings()
+	s.process(ctx)
+
+	interval := s.settings.get().SendInterval
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ticker.C:
-			s.process()
-		case <-s.ctx.Done():
+			s.refreshSettings()
+			s.process(ctx)
+		case <-s.settings.changed:
+			if next := s.settings.get().SendInterval; next != interval {
+				log.Printf("Send interval changed from %s to %s", interval, next)
+				interval = next
+				ticker.Reset(interval)
+			}
+		case <-ctx.Done():
 			log.Println("Scheduler context cancelled, stopping...")
 			return
 		}
 	}
 }
 
-func (s *Scheduler) process() {
-	msgs, err := s.repo.FetchUnsent(2)
-	if err != nil {
-		log.Printf("DB fetch error: %v", err)
+// reapLeases periodically returns messages whose lease expired (e.g. their replica crashed) to pending
+func (s *Scheduler) reapLeases(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.LeaseReapInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			n, err := s.repo.ReleaseExpiredLeases(ctx)
+			if err != nil {
+				log.Printf("Failed to release expired leases: %v", err)
+				continue
+			}
+			if n > 0 {
+				log.Printf("Released %d messages with expired leases back to pending", n)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *Scheduler) process(ctx context.Context) {
+	ctx, span := tracer.Start(ctx, "scheduler.process")
+	defer span.End()
+
+	metrics.SchedulerTicks.Inc()
+	settings := s.settings.get()
+
+	if n, err := s.repo.ExpireStale(ctx); err != nil {
+		log.Printf("Failed to expire stale messages: %v", err)
+	} else if n > 0 {
+		log.Printf("Expired %d messages past their validity period", n)
+	}
+
+	// Leave messages in the database while no provider can take them instead of burning their attempts
+	if retryAt, down := s.router.Unavailable(s.router.Providers()); down {
+		log.Printf("All provider circuits are open, pausing until %s", retryAt.Format(time.RFC3339))
+		span.AddEvent("all provider circuits open")
 		return
 	}
 
-	log.Printf("Fetched %d unsent messages", len(msgs))
+	msgs := s.claim(ctx, settings.BatchSize)
+	log.Printf("Claimed %d unsent messages", len(msgs))
+	metrics.BatchSize.Observe(float64(len(msgs)))
+	span.SetAttributes(attribute.Int("scheduler.batch_size", settings.BatchSize), attribute.Int("scheduler.claimed", len(msgs)))
 
+	// Bound the number of in-flight webhook calls
+	sem := make(chan struct{}, settings.MaxConcurrency)
 	var wg sync.WaitGroup
 	for _, m := range msgs {
 		wg.Add(1)
+		sem <- struct{}{}
 		go func(msg model.Message) {
 			defer wg.Done()
-			s.sendMessage(s.ctx, msg)
+			defer func() { <-sem }()
+			s.sendMessage(ctx, msg)
 		}(m)
 	}
 	wg.Wait()
 }
 
-const (
-	maxMessageLength = 160
-	redisKeyPrefix   = "insider:msg:sent"
-	maxRetries       = 3
-	baseDelay        = 1 * time.Second
-)
+// claim reserves up to batchSize messages, giving each priority class its share of the batch
+// and backfilling capacity left unused by classes with a short backlog
+func (s *Scheduler) claim(ctx context.Context, batchSize int) []model.Message {
+	var msgs []model.Message
+
+	quotas := s.priorities.allocate(batchSize)
+	for _, class := range constants.MessagePriorityValues() {
+		if quotas[class] == 0 {
+			continue
+		}
+		claimed, err := s.repo.ClaimUnsent(ctx, s.cfg.InstanceID, class, quotas[class], s.cfg.LeaseDuration)
+		if err != nil {
+			log.Printf("DB fetch error (%s priority): %v", class, err)
+			return msgs
+		}
+		s.priorities.settle(class, quotas[class], len(claimed))
+		msgs = append(msgs, claimed...)
+	}
 
+	if remaining := batchSize - len(msgs); remaining > 0 {
+		claimed, err := s.repo.ClaimUnsent(ctx, s.cfg.InstanceID, "", remaining, s.cfg.LeaseDuration)
+		if err != nil {
+			log.Printf("DB fetch error: %v", err)
+			return msgs
+		}
+		msgs = append(msgs, claimed...)
+	}
+	return msgs
+}
+
+const recordIOTimeout = 5 * time.Second
+
+// errLeaseLost marks a send that was not attempted because the message's lease could not be renewed
+var errLeaseLost = errors.New("lease could not be renewed")
+
+// sendMessage makes a single delivery attempt and persists its outcome. Failed attempts are handed
+// back to the database with a next_attempt_at, so retries survive restarts and run on later ticks.
 func (s *Scheduler) sendMessage(ctx context.Context, m model.Message) {
-	if len(m.Content) > maxMessageLength {
-		log.Printf("Message %d content too long (%d chars), skipping", m.ID, len(m.Content))
+	// Outcomes are written even when a shutdown cancels ctx in the middle of the attempt
+	recordCtx := context.WithoutCancel(ctx)
+
+	if err := validator.ValidateContent(m.Content); err != nil {
+		log.Printf("Message %d content invalid (%v), marking as failed", m.ID, err)
+		failure := model.SendFailure{
+			Attempts:   m.AttemptCount,
+			Error:      "invalid content: " + err.Error(),
+			ErrorClass: constants.ErrorClassPermanent,
+		}
+		if err := s.repo.MarkAsFailed(recordCtx, m.ID, s.cfg.InstanceID, failure); err != nil {
+			log.Printf("Failed to mark msg %d as failed in DB: %v", m.ID, err)
+		}
 		return
 	}
 
-	body, err := json.Marshal(map[string]string{
-		"to":      m.PhoneNumber,
-		"content": m.Content,
-	})
-	if err != nil {
-		log.Printf("Failed to marshal message %d: %v", m.ID, err)
+	// The provider may have accepted an earlier attempt whose outcome never reached the database
+	if s.alreadyDelivered(ctx, m) {
 		return
 	}
 
-	// Retry mechanism for rate limiting and temporary failures
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		success := s.sendMessageWithRetry(ctx, m, body, attempt)
-		if success {
-			return
+	// A retry can be due after the validity period, a late OTP is worse than none
+	if m.ExpiresAt != nil && !time.Now().Before(*m.ExpiresAt) {
+		log.Printf("Message %d expired at %s, not sending", m.ID, m.ExpiresAt.Format(time.RFC3339))
+		if err := s.repo.MarkAsExpired(recordCtx, m.ID, s.cfg.InstanceID); err != nil {
+			log.Printf("Failed to mark msg %d as expired in DB: %v", m.ID, err)
 		}
+		return
+	}
 
-		// Don't retry on last attempt
-		if attempt == maxRetries-1 {
-			log.Printf("Message %d failed after %d attempts, marking as failed", m.ID, maxRetries)
-			if err := s.repo.MarkAsFailed(m.ID); err != nil {
-				log.Printf("Failed to mark msg %d as failed in DB: %v", m.ID, err)
-			}
-			return
+	attempt := m.AttemptCount + 1
+	used, sendErr := s.sendMessageWithRetry(ctx, m, attempt)
+	if sendErr == nil {
+		return
+	}
+
+	// A cancelled attempt says nothing about the message, hand it back instead of counting it
+	if ctx.Err() != nil {
+		s.releaseLease(recordCtx, m)
+		return
+	}
+
+	// Neither does a provider outage: wait for the circuit to let a probe through without counting the attempt
+	if errors.Is(sendErr, provider.ErrCircuitOpen) {
+		retryAt, _ := s.router.Unavailable(s.router.Route(m))
+		log.Printf("Message %d deferred until %s, provider circuit open", m.ID, retryAt.Format(time.RFC3339))
+		if err := s.repo.DeferAttempt(recordCtx, m.ID, s.cfg.InstanceID, sendErr.Error(), retryAt); err != nil {
+			log.Printf("Failed to defer msg %d in DB: %v", m.ID, err)
 		}
+		return
+	}
+
+	// The message was not sent and another replica may own it by now, leave its state alone
+	if errors.Is(sendErr, errLeaseLost) {
+		log.Printf("Message %d not sent: %v", m.ID, sendErr)
+		return
+	}
 
-		// Calculate delay with exponential backoff
-		delay := baseDelay * time.Duration(1<<attempt) // 1s, 2s, 4s
-		log.Printf("Message %d attempt %d failed, retrying in %v", m.ID, attempt+1, delay)
+	failure := model.SendFailure{
+		Attempts:     attempt,
+		Error:        sendErr.Error(),
+		ErrorClass:   sendErr.Class,
+		ProviderCode: sendErr.Code,
+		Provider:     used,
+		StatusCode:   sendErr.StatusCode,
+	}
 
-		select {
-		case <-ctx.Done():
-			log.Printf("Message %d retry cancelled due to context cancellation", m.ID)
-			return
-		case <-time.After(delay):
-			// Continue to next attempt
+	if !sendErr.Retryable() {
+		log.Printf("Message %d failed permanently on attempt %d (%s), marking as failed", m.ID, attempt, sendErr)
+		if err := s.repo.MarkAsFailed(recordCtx, m.ID, s.cfg.InstanceID, failure); err != nil {
+			log.Printf("Failed to mark msg %d as failed in DB: %v", m.ID, err)
 		}
+		return
+	}
+
+	if attempt >= s.cfg.RetryMaxAttempts {
+		log.Printf("Message %d failed after %d attempts, marking as failed", m.ID, attempt)
+		if err := s.repo.MarkAsFailed(recordCtx, m.ID, s.cfg.InstanceID, failure); err != nil {
+			log.Printf("Failed to mark msg %d as failed in DB: %v", m.ID, err)
+		}
+		return
+	}
+
+	delay := s.backoff.RetryDelay(attempt, sendErr.RetryAfter)
+	nextAttemptAt := time.Now().Add(delay)
+	log.Printf("Message %d attempt %d failed, retrying in %v", m.ID, attempt, delay.Round(time.Second))
+	metrics.Retries.Inc()
+	if err := s.repo.ScheduleRetry(recordCtx, m.ID, s.cfg.InstanceID, failure, nextAttemptAt); err != nil {
+		log.Printf("Failed to schedule retry of msg %d in DB: %v", m.ID, err)
 	}
 }
 
-func (s *Scheduler) sendMessageWithRetry(ctx context.Context, m model.Message, body []byte, attempt int) bool {
-	// Create request with context
-	req, err := http.NewRequestWithContext(ctx, "POST", s.cfg.WebhookURL, bytes.NewBuffer(body))
+// alreadyDelivered marks m as sent without sending it again if Redis remembers its delivery.
+// If Redis cannot be asked the send goes ahead; the provider-side idempotency key still protects it.
+func (s *Scheduler) alreadyDelivered(ctx context.Context, m model.Message) bool {
+	o, err := s.cache.LookupDelivery(ctx, m.ID)
 	if err != nil {
-		log.Printf("Failed to create request for msg %d (attempt %d): %v", m.ID, attempt+1, err)
+		log.Printf("Failed to look up delivery of msg %d, sending anyway: %v", m.ID, err)
 		return false
 	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := s.client.Do(req)
-	if err != nil {
-		log.Printf("Failed to send msg %d (attempt %d): %v", m.ID, attempt+1, err)
+	if o == nil {
 		return false
 	}
-	defer resp.Body.Close() //nolint:errcheck
 
-	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusCreated || resp.StatusCode == http.StatusAccepted {
-		log.Printf("Message %d sent successfully (attempt %d)", m.ID, attempt+1)
+	log.Printf("Message %d was already delivered via %s at %s, recording it without resending",
+		m.ID, o.Provider, o.SentAt.Format(time.RFC3339))
+	if err := s.persistSent(ctx, *o); err != nil {
+		// The lease will lapse and the next claim finds the delivery record again
+		log.Printf("Failed to mark msg %d as sent in DB: %v", m.ID, err)
+	}
+	return true
+}
+
+func (s *Scheduler) releaseLease(ctx context.Context, m model.Message) {
+	if err := s.repo.ReleaseLease(ctx, m.ID, s.cfg.InstanceID); err != nil {
+		log.Printf("Failed to release lease on msg %d: %v", m.ID, err)
+	}
+}
+
+// sendMessageWithRetry performs one delivery attempt and records a success. The attempt goes to the routed
+// provider and fails over to the next candidate on a transient failure; a permanent failure is the message's
+// fault and would fail everywhere. Providers whose circuit is open are skipped, and when every candidate's
+// circuit is open afterwards the error wraps provider.ErrCircuitOpen.
+// It returns the last provider tried; attempt is 1-based and only used for logging and tracing.
+func (s *Scheduler) sendMessageWithRetry(ctx context.Context, m model.Message, attempt int) (used string, sendErr *provider.SendError) {
+	ctx, span := tracer.Start(ctx, "scheduler.send_attempt", trace.WithAttributes(
+		attribute.Int64("message.id", m.ID),
+		attribute.String("message.priority", m.Priority),
+		attribute.Int("message.attempt", attempt),
+	))
+	defer func() {
+		span.SetAttributes(attribute.String("provider.name", used))
+		if sendErr != nil {
+			span.SetAttributes(attribute.String("error.class", sendErr.Class))
+			if sendErr.StatusCode != 0 {
+				span.SetAttributes(attribute.Int("http.response.status_code", sendErr.StatusCode))
+			}
+			span.SetStatus(codes.Error, sendErr.Error())
+		}
+		span.End()
+	}()
+
+	candidates := s.router.Route(m)
 
-		var respData struct {
-			MessageID string `json:"messageId"`
+	for i, p := range candidates {
+		breaker := s.router.Breaker(p.Name())
+		if !breaker.Allow() {
+			continue
 		}
-		if err := json.NewDecoder(resp.Body).Decode(&respData); err != nil {
-			log.Printf("Failed to parse webhook response for msg %d (attempt %d): %v", m.ID, attempt+1, err)
-			return false
+
+		used = p.Name()
+		if err := s.router.Limiter(used).Wait(ctx); err != nil {
+			breaker.Release()
+			return used, provider.AsSendError(err)
 		}
 
-		// Use the same timestamp for both DB and cache
-		sentAt := time.Now()
+		// Waiting for concurrency and rate limit slots can outlast the lease; renew it right before sending
+		// so the message is not reaped and claimed by another replica mid-send
+		if err := s.repo.ExtendLease(ctx, m.ID, s.cfg.InstanceID, s.cfg.LeaseDuration); err != nil {
+			breaker.Release()
+			return used, &provider.SendError{
+				Class: constants.ErrorClassTransient,
+				Err:   fmt.Errorf("%w: %v", errLeaseLost, err),
+			}
+		}
 
-		// Mark DB as sent
-		if err := s.repo.MarkAsSent(m.ID); err != nil {
-			log.Printf("Failed to mark msg %d as sent in DB: %v", m.ID, err)
+		start := time.Now()
+		res, err := p.Send(ctx, m)
+		latency := time.Since(start)
+		if err == nil {
+			span.SetAttributes(attribute.Int("http.response.status_code", res.StatusCode))
+			metrics.ObserveSend(used, metrics.OutcomeSent, res.StatusCode, latency)
+			breaker.Success()
+			s.recordSent(ctx, m, attempt, used, res, latency)
+			return used, nil
 		}
 
-		// Cache messageId + sending time
-		if respData.MessageID != "" {
-			cacheKey := fmt.Sprintf("%s:%s", redisKeyPrefix, respData.MessageID)
-			cacheVal := sentAt.Format(time.RFC3339)
+		sendErr = provider.AsSendError(err)
+		outcome := metrics.OutcomeTransient
+		if !sendErr.Retryable() {
+			outcome = metrics.OutcomePermanent
+		}
+		metrics.ObserveSend(used, outcome, sendErr.StatusCode, latency)
+		s.recordAttempt(ctx, m, attempt, used, latency, sendErr.StatusCode, sendErr.Error())
+		if ctx.Err() != nil {
+			breaker.Release()
+			return used, sendErr
+		}
 
-			if err := s.cache.Set(ctx, cacheKey, cacheVal, 0); err != nil {
-				log.Printf("Failed to cache messageId %s: %v", respData.MessageID, err)
-			} else {
-				log.Printf("Cached messageId=%s sent_at=%s", respData.MessageID, cacheVal)
+		if sendErr.StatusCode != 0 {
+			log.Printf("Failed to send msg %d via %s (attempt %d): %s [%s, code=%s]",
+				m.ID, used, attempt, sendErr, sendErr.Class, sendErr.Code)
+		} else {
+			log.Printf("Failed to send msg %d via %s (attempt %d): %v", m.ID, used, attempt, sendErr)
+		}
+
+		if !sendErr.Retryable() {
+			// The provider answered, so it is up even though it rejected the message
+			breaker.Success()
+			return used, sendErr
+		}
+		if breaker.Failure() {
+			log.Printf("Circuit for provider %s is open after %d consecutive failures",
+				used, breaker.Status().ConsecutiveFailures)
+		}
+		if i+1 < len(candidates) {
+			log.Printf("Failing over msg %d from %s to %s", m.ID, used, candidates[i+1].Name())
+			span.AddEvent("failover", trace.WithAttributes(
+				attribute.String("provider.from", used),
+				attribute.String("provider.to", candidates[i+1].Name()),
+			))
+		}
+	}
+
+	if _, down := s.router.Unavailable(candidates); down {
+		cause := "no provider accepted the message"
+		if sendErr != nil {
+			cause = sendErr.Error()
+		}
+		return used, &provider.SendError{
+			Class: constants.ErrorClassTransient,
+			Err:   fmt.Errorf("%w: %s", provider.ErrCircuitOpen, cause),
+		}
+	}
+	if sendErr == nil {
+		// Every candidate was busy with a half-open probe; try again on a later tick
+		return used, &provider.SendError{
+			Class: constants.ErrorClassTransient,
+			Err:   fmt.Errorf("%w: waiting for a probe to finish", provider.ErrCircuitOpen),
+		}
+	}
+	return used, sendErr
+}
+
+// recordSent records a send the provider accepted. The delivery is remembered in Redis first so that no
+// later failure can lead to a resend; if the database write fails the outcome is queued for reconciliation.
+func (s *Scheduler) recordSent(ctx context.Context, m model.Message, attempt int, used string, res *provider.Result, latency time.Duration) {
+	log.Printf("Message %d sent successfully via %s (attempt %d)", m.ID, used, attempt)
+
+	o := model.SentOutcome{
+		MessageID:         m.ID,
+		Attempts:          attempt,
+		Provider:          used,
+		ProviderMessageID: res.MessageID,
+		StatusCode:        res.StatusCode,
+		SentAt:            time.Now(),
+	}
+	if res.ResponseError != nil {
+		log.Printf("Provider %s accepted msg %d but its response was unreadable: %v", used, m.ID, res.ResponseError)
+		o.Note = "accepted with an unreadable provider response: " + res.ResponseError.Error()
+	}
+
+	// The outcome must be recorded even when a shutdown cancels ctx right after the provider accepted the message
+	recordCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), recordIOTimeout)
+	defer cancel()
+
+	if err := s.cache.RecordDelivery(recordCtx, o, s.cfg.DeliveryDedupTTL); err != nil {
+		log.Printf("Failed to record delivery of msg %d: %v", m.ID, err)
+	}
+	s.recordAttempt(recordCtx, m, attempt, used, latency, res.StatusCode, o.Note)
+
+	if err := s.persistSent(recordCtx, o); err != nil {
+		log.Printf("Failed to mark msg %d as sent in DB, queueing it for reconciliation: %v", m.ID, err)
+		if err := s.cache.EnqueueReconciliation(recordCtx, o); err != nil {
+			log.Printf("Failed to queue msg %d for reconciliation: %v", m.ID, err)
+		}
+	}
+}
+
+// recordAttempt adds a provider call to the message's timeline; a lost event only costs history
+func (s *Scheduler) recordAttempt(ctx context.Context, m model.Message, attempt int, used string, latency time.Duration, statusCode int, detail string) {
+	e := model.MessageEvent{
+		MessageID:  m.ID,
+		Type:       constants.MessageEventAttempt,
+		Attempt:    attempt,
+		Provider:   used,
+		StatusCode: statusCode,
+		LatencyMs:  int(latency.Milliseconds()),
+		Detail:     detail,
+	}
+	if err := s.repo.RecordEvent(context.WithoutCancel(ctx), e); err != nil {
+		log.Printf("Failed to record attempt %d of msg %d: %v", attempt, m.ID, err)
+	}
+}
+
+// persistSent stores an accepted send in the database and, once stored, caches the provider's messageId
+// with a link back to the message row
+func (s *Scheduler) persistSent(ctx context.Context, o model.SentOutcome) error {
+	if err := s.repo.MarkAsSent(ctx, o); err != nil {
+		return err
+	}
+
+	if o.ProviderMessageID != "" {
+		if err := s.cache.CacheSent(ctx, o, s.cfg.SentCacheTTL); err != nil {
+			log.Printf("Failed to cache messageId %s: %v", o.ProviderMessageID, err)
+		} else {
+			log.Printf("Cached messageId=%s id=%d sent_at=%s", o.ProviderMessageID, o.MessageID, o.SentAt.Format(time.RFC3339))
+		}
+	}
+	return nil
+}
+
+// reconcile periodically persists send outcomes that could not be written when their message was sent
+func (s *Scheduler) reconcile(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.ReconcileInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.drainReconciliation(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *Scheduler) drainReconciliation(ctx context.Context) {
+	for ctx.Err() == nil {
+		o, err := s.cache.DequeueReconciliation(ctx)
+		if err != nil {
+			log.Printf("Failed to read reconciliation queue: %v", err)
+			return
+		}
+		if o == nil {
+			return
+		}
+
+		if err := s.persistSent(ctx, *o); err != nil {
+			log.Printf("Failed to reconcile msg %d, will retry: %v", o.MessageID, err)
+			if err := s.cache.EnqueueReconciliation(context.WithoutCancel(ctx), *o); err != nil {
+				log.Printf("Failed to requeue msg %d for reconciliation: %v", o.MessageID, err)
 			}
+			return
 		}
-		return true
-	} else {
-		log.Printf("Failed to send msg %d (attempt %d): %s", m.ID, attempt+1, resp.Status)
-		// Read response body to avoid connection leak
-		_, _ = io.Copy(io.Discard, resp.Body)
-		return false // Will retry
+		log.Printf("Reconciled msg %d as sent via %s", o.MessageID, o.Provider)
 	}
 }
+
+// ReconciliationBacklog returns how many send outcomes are waiting to be persisted
+func (s *Scheduler) ReconciliationBacklog(ctx context.Context) (int64, error) {
+	return s.cache.ReconciliationBacklog(ctx)
+}
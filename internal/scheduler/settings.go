@@ -0,0 +1,162 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+const settingsKey = "insider:scheduler:settings"
+
+// Limits for runtime-tunable settings
+const (
+	MaxBatchSize      = 1000
+	MaxConcurrency    = 100
+	MinSendInterval   = time.Second
+	settingsIOTimeout = 2 * time.Second
+)
+
+// Settings are the scheduler knobs that can be changed at runtime without a restart
+type Settings struct {
+	BatchSize      int           `json:"batch_size"`
+	MaxConcurrency int           `json:"max_concurrency"`
+	SendInterval   time.Duration `json:"send_interval"`
+}
+
+// SettingsError describes a setting that is out of range
+type SettingsError struct {
+	Field   string
+	Message string
+}
+
+func (e *SettingsError) Error() string {
+	return e.Field + " " + e.Message
+}
+
+// Validate returns a *SettingsError describing the first out-of-range setting
+func (st Settings) Validate() error {
+	if st.BatchSize < 1 || st.BatchSize > MaxBatchSize {
+		return &SettingsError{Field: "batch_size", Message: fmt.Sprintf("must be between 1 and %d", MaxBatchSize)}
+	}
+	if st.MaxConcurrency < 1 || st.MaxConcurrency > MaxConcurrency {
+		return &SettingsError{Field: "max_concurrency", Message: fmt.Sprintf("must be between 1 and %d", MaxConcurrency)}
+	}
+	if st.SendInterval < MinSendInterval {
+		return &SettingsError{Field: "send_interval", Message: fmt.Sprintf("must be at least %s", MinSendInterval)}
+	}
+	return nil
+}
+
+// settingsStore keeps the settings in effect and shares updates with other replicas through Redis.
+// defaults are the settings from the environment, which apply whenever Redis holds none.
+type settingsStore struct {
+	mu       sync.RWMutex
+	current  Settings
+	defaults Settings
+	changed  chan struct{}
+}
+
+func newSettingsStore(initial Settings) *settingsStore {
+	return &settingsStore{
+		current:  initial,
+		defaults: initial,
+		changed:  make(chan struct{}, 1),
+	}
+}
+
+func (st *settingsStore) get() Settings {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.current
+}
+
+func (st *settingsStore) set(v Settings) {
+	st.mu.Lock()
+	changed := st.current != v
+	st.current = v
+	st.mu.Unlock()
+
+	if changed {
+		// Wake up the loop so a new interval replaces the running ticker
+		select {
+		case st.changed <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Settings returns the scheduler settings currently in effect, picking up changes made on other replicas
+func (s *Scheduler) Settings() Settings {
+	s.refreshSettings()
+	return s.settings.get()
+}
+
+// UpdateSettings validates and applies new settings; they take effect on the next tick. The settings are kept in
+// Redis without expiry, so they override the environment on every replica and across restarts until reset.
+func (s *Scheduler) UpdateSettings(v Settings) error {
+	if err := v.Validate(); err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), settingsIOTimeout)
+	defer cancel()
+	if err := s.cache.Set(ctx, settingsKey, string(payload), 0); err != nil {
+		return fmt.Errorf("failed to store scheduler settings: %w", err)
+	}
+
+	s.settings.set(v)
+	log.Printf("Scheduler settings updated: batch_size=%d max_concurrency=%d send_interval=%s",
+		v.BatchSize, v.MaxConcurrency, v.SendInterval)
+	return nil
+}
+
+// ResetSettings removes the shared settings from Redis, so every replica goes back to its environment settings
+func (s *Scheduler) ResetSettings() error {
+	ctx, cancel := context.WithTimeout(context.Background(), settingsIOTimeout)
+	defer cancel()
+	if err := s.cache.Delete(ctx, settingsKey); err != nil {
+		return fmt.Errorf("failed to reset scheduler settings: %w", err)
+	}
+
+	v := s.settings.defaults
+	s.settings.set(v)
+	log.Printf("Scheduler settings reset to environment: batch_size=%d max_concurrency=%d send_interval=%s",
+		v.BatchSize, v.MaxConcurrency, v.SendInterval)
+	return nil
+}
+
+// refreshSettings loads the shared settings from Redis, keeping the current ones if Redis is unavailable
+func (s *Scheduler) refreshSettings() {
+	ctx, cancel := context.WithTimeout(context.Background(), settingsIOTimeout)
+	defer cancel()
+
+	payload, err := s.cache.Get(ctx, settingsKey)
+	if err != nil {
+		log.Printf("Failed to load scheduler settings, keeping current ones: %v", err)
+		return
+	}
+	if payload == "" {
+		// Nothing stored, or reset on another replica
+		s.settings.set(s.settings.defaults)
+		return
+	}
+
+	var v Settings
+	if err := json.Unmarshal([]byte(payload), &v); err != nil {
+		log.Printf("Ignoring malformed scheduler settings: %v", err)
+		return
+	}
+	if err := v.Validate(); err != nil {
+		log.Printf("Ignoring invalid scheduler settings: %v", err)
+		return
+	}
+	s.settings.set(v)
+}
@@ -0,0 +1,156 @@
+package validator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"insider-message-sender/internal/constants"
+	"insider-message-sender/internal/model"
+)
+
+var phoneNumberPattern = regexp.MustCompile(`^\+[0-9]{10,15}$`)
+
+// ValidatePhoneNumber checks that the phone number is in E.164 format (e.g. +84901234567)
+func ValidatePhoneNumber(phoneNumber string) error {
+	if phoneNumber == "" {
+		return fmt.Errorf("is required")
+	}
+	if !phoneNumberPattern.MatchString(phoneNumber) {
+		return fmt.Errorf("must be in E.164 format, e.g. +84901234567")
+	}
+	return nil
+}
+
+// ValidateContent checks that the content is not blank and fits into a single SMS
+func ValidateContent(content string) error {
+	if strings.TrimSpace(content) == "" {
+		return fmt.Errorf("is required")
+	}
+	if n := utf8.RuneCountInString(content); n > constants.MaxMessageLength {
+		return fmt.Errorf("must be at most %d characters, got %d", constants.MaxMessageLength, n)
+	}
+	return nil
+}
+
+// ValidateMessage validates all user-provided fields of a message and returns one error per invalid field
+func ValidateMessage(phoneNumber, content string) []model.FieldError {
+	var errs []model.FieldError
+	if err := ValidatePhoneNumber(phoneNumber); err != nil {
+		errs = append(errs, model.FieldError{Field: "phone_number", Message: err.Error()})
+	}
+	if err := ValidateContent(content); err != nil {
+		errs = append(errs, model.FieldError{Field: "content", Message: err.Error()})
+	}
+	return errs
+}
+
+// ValidateSchedule checks that an expiring message can still be sent: its expiry must be in the future and after send_at
+func ValidateSchedule(sendAt, expiresAt *time.Time) []model.FieldError {
+	if expiresAt == nil {
+		return nil
+	}
+	if !expiresAt.After(time.Now()) {
+		return []model.FieldError{{Field: "expires_at", Message: "must be in the future"}}
+	}
+	if sendAt != nil && !expiresAt.After(*sendAt) {
+		return []model.FieldError{{Field: "expires_at", Message: "must be after send_at"}}
+	}
+	return nil
+}
+
+// ValidateCreateRequest validates every field of a message create request
+func ValidateCreateRequest(req model.CreateMessageRequest) []model.FieldError {
+	errs := ValidateMessage(req.PhoneNumber, req.Content)
+	if req.Priority != "" && !constants.IsValidMessagePriority(req.Priority) {
+		errs = append(errs, model.FieldError{
+			Field:   "priority",
+			Message: "must be one of " + strings.Join(constants.MessagePriorityValues(), ", "),
+		})
+	}
+	return append(errs, ValidateSchedule(req.SendAt, req.ExpiresAt)...)
+}
+
+// ValidateDeliveryReport checks that a delivery receipt names a provider messageId and a final delivery status
+func ValidateDeliveryReport(req model.DeliveryReportRequest) []model.FieldError {
+	var errs []model.FieldError
+	if strings.TrimSpace(req.MessageID) == "" {
+		errs = append(errs, model.FieldError{Field: "messageId", Message: "messageId is required"})
+	}
+	if !constants.IsValidDeliveryStatus(req.Status) {
+		errs = append(errs, model.FieldError{
+			Field:   "status",
+			Message: "must be one of " + constants.MessageStatusDelivered + ", " + constants.MessageStatusUndelivered,
+		})
+	}
+	return errs
+}
+
+// ValidateListMessagesRequest checks the filters and sort of a message search
+func ValidateListMessagesRequest(req model.ListMessagesRequest) []model.FieldError {
+	var errs []model.FieldError
+	for _, status := range req.Status {
+		if !constants.IsValidMessageStatus(status) {
+			errs = append(errs, model.FieldError{
+				Field:   "status",
+				Message: "must be one of " + strings.Join(constants.MessageStatusValues(), ", "),
+			})
+			break
+		}
+	}
+	if !constants.IsValidMessageSort(strings.TrimPrefix(req.Sort, "-")) {
+		errs = append(errs, model.FieldError{
+			Field:   "sort",
+			Message: "must be one of " + strings.Join(constants.MessageSortValues(), ", ") + ", optionally prefixed with -",
+		})
+	}
+	if req.CreatedFrom != nil && req.CreatedTo != nil && !req.CreatedTo.After(*req.CreatedFrom) {
+		errs = append(errs, model.FieldError{Field: "created_to", Message: "must be after created_from"})
+	}
+	if req.SentFrom != nil && req.SentTo != nil && !req.SentTo.After(*req.SentFrom) {
+		errs = append(errs, model.FieldError{Field: "sent_to", Message: "must be after sent_from"})
+	}
+	if req.Offset != nil {
+		switch {
+		case *req.Offset < 0:
+			errs = append(errs, model.FieldError{Field: "offset", Message: "must not be negative"})
+		case req.Cursor != "":
+			errs = append(errs, model.FieldError{Field: "offset", Message: "cannot be combined with cursor"})
+		}
+	}
+	return errs
+}
+
+// MaxRequeueBatch bounds how many failed messages a single requeue request may send back to pending
+const MaxRequeueBatch = 10000
+
+// ValidateRequeueRequest checks that a requeue request selects messages either by id or by at least one filter
+func ValidateRequeueRequest(req model.RequeueMessagesRequest) []model.FieldError {
+	var errs []model.FieldError
+	hasFilter := req.FailedFrom != nil || req.FailedTo != nil || req.ErrorClass != ""
+
+	switch {
+	case len(req.IDs) == 0 && !hasFilter:
+		errs = append(errs, model.FieldError{Field: "ids", Message: "ids or at least one filter (failed_from, failed_to, error_class) is required"})
+	case len(req.IDs) > 0 && hasFilter:
+		errs = append(errs, model.FieldError{Field: "ids", Message: "cannot be combined with filters"})
+	case len(req.IDs) > MaxRequeueBatch:
+		errs = append(errs, model.FieldError{Field: "ids", Message: fmt.Sprintf("must contain at most %d ids", MaxRequeueBatch)})
+	}
+
+	if req.ErrorClass != "" && !constants.IsValidErrorClass(req.ErrorClass) {
+		errs = append(errs, model.FieldError{
+			Field:   "error_class",
+			Message: "must be one of " + strings.Join(constants.ErrorClassValues(), ", "),
+		})
+	}
+	if req.FailedFrom != nil && req.FailedTo != nil && !req.FailedTo.After(*req.FailedFrom) {
+		errs = append(errs, model.FieldError{Field: "failed_to", Message: "must be after failed_from"})
+	}
+	if req.Limit < 0 || req.Limit > MaxRequeueBatch {
+		errs = append(errs, model.FieldError{Field: "limit", Message: fmt.Sprintf("must be between 0 and %d (0 = default)", MaxRequeueBatch)})
+	}
+	return errs
+}
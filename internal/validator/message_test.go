@@ -0,0 +1,64 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+
+	"insider-message-sender/internal/constants"
+)
+
+func TestValidatePhoneNumber(t *testing.T) {
+	tests := []struct {
+		phone string
+		valid bool
+	}{
+		{"+84901234567", true},
+		{"+905551234567", true},
+		{"+1234567890", true},
+		{"+123456789012345", true},
+		{"", false},
+		{"84901234567", false},
+		{"+123456789", false},
+		{"+1234567890123456", false},
+		{"+8490123456a", false},
+		{"+84 901 234 567", false},
+		{" +84901234567", false},
+	}
+	for _, tt := range tests {
+		if err := ValidatePhoneNumber(tt.phone); (err == nil) != tt.valid {
+			t.Errorf("ValidatePhoneNumber(%q) = %v, want valid=%v", tt.phone, err, tt.valid)
+		}
+	}
+}
+
+func TestValidateContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		valid   bool
+	}{
+		{"plain", "Your code is 1234", true},
+		{"empty", "", false},
+		{"blank", " \t\n", false},
+		{"at limit", strings.Repeat("a", constants.MaxMessageLength), true},
+		{"over limit", strings.Repeat("a", constants.MaxMessageLength+1), false},
+		// Multi-byte characters count once, so a limit-long Turkish text is still accepted
+		{"runes at limit", strings.Repeat("ş", constants.MaxMessageLength), true},
+		{"runes over limit", strings.Repeat("ş", constants.MaxMessageLength+1), false},
+	}
+	for _, tt := range tests {
+		if err := ValidateContent(tt.content); (err == nil) != tt.valid {
+			t.Errorf("%s: ValidateContent = %v, want valid=%v", tt.name, err, tt.valid)
+		}
+	}
+}
+
+func TestValidateMessageReportsEveryInvalidField(t *testing.T) {
+	errs := ValidateMessage("12345", "")
+	if len(errs) != 2 || errs[0].Field != "phone_number" || errs[1].Field != "content" {
+		t.Fatalf("ValidateMessage = %+v, want phone_number and content errors", errs)
+	}
+	if errs := ValidateMessage("+84901234567", "hello"); len(errs) != 0 {
+		t.Fatalf("ValidateMessage on a valid message = %+v, want none", errs)
+	}
+}
@@ -1,17 +1,101 @@
 -- Create enum type for message status
-CREATE TYPE message_status AS ENUM ('pending', 'sent', 'failed');
+CREATE TYPE message_status AS ENUM ('pending', 'in_progress', 'sent', 'failed', 'expired', 'delivered', 'undelivered');
+
+-- Create enum type for classifying send failures
+CREATE TYPE error_class AS ENUM ('transient', 'permanent');
+
+-- Create enum type for message priority, ordered from least to most urgent
+CREATE TYPE message_priority AS ENUM ('low', 'normal', 'high');
 
 CREATE TABLE IF NOT EXISTS messages (
     id SERIAL PRIMARY KEY,
     phone_number VARCHAR(20) NOT NULL,
     content TEXT NOT NULL CHECK (char_length(content) <= 160),
     status message_status DEFAULT 'pending',
-    sent_at TIMESTAMPTZ
+    priority message_priority NOT NULL DEFAULT 'normal',
+    sent_at TIMESTAMPTZ,
+    failed_at TIMESTAMPTZ,
+    send_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ,
+    attempt_count INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMPTZ,
+    error_class error_class,
+    provider_error_code TEXT,
+    provider TEXT,
+    provider_message_id TEXT,
+    last_status_code INTEGER,
+    delivery_reported_at TIMESTAMPTZ,
+    delivery_reason TEXT,
+    idempotency_key TEXT UNIQUE,
+    lease_owner TEXT,
+    lease_expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
 );
 
 -- Create indexes for better performance
 CREATE INDEX IF NOT EXISTS idx_messages_status ON messages(status);
 CREATE INDEX IF NOT EXISTS idx_messages_sent_at ON messages(sent_at);
+CREATE INDEX IF NOT EXISTS idx_messages_failed_at ON messages(failed_at, id) WHERE failed_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_messages_send_at ON messages(send_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_messages_priority_send_at ON messages(priority, send_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_messages_expires_at ON messages(expires_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_messages_lease_expires_at ON messages(lease_expires_at) WHERE status = 'in_progress';
+CREATE INDEX IF NOT EXISTS idx_messages_created_at ON messages(created_at, id);
+CREATE INDEX IF NOT EXISTS idx_messages_provider_message_id ON messages(provider_message_id) WHERE provider_message_id IS NOT NULL;
+
+-- Audit trail of failed messages sent back to pending
+CREATE TABLE IF NOT EXISTS message_requeues (
+    id BIGSERIAL PRIMARY KEY,
+    message_id INTEGER NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    previous_attempt_count INTEGER NOT NULL,
+    previous_error TEXT,
+    previous_error_class error_class,
+    requested_by TEXT NOT NULL,
+    reason TEXT,
+    requeued_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_requeues_message_id ON message_requeues(message_id);
+
+-- Create enum type for message timeline events
+CREATE TYPE message_event_type AS ENUM ('created', 'claimed', 'attempt', 'sent', 'retry_scheduled', 'deferred', 'released',
+                                        'failed', 'expired', 'requeued', 'delivered', 'undelivered');
+
+-- Append-only timeline of every transition of a message
+CREATE TABLE IF NOT EXISTS message_events (
+    id BIGSERIAL PRIMARY KEY,
+    message_id INTEGER NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    type message_event_type NOT NULL,
+    attempt INTEGER,
+    provider TEXT,
+    status_code INTEGER,
+    latency_ms INTEGER,
+    detail TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_events_message_id ON message_events(message_id, created_at);
+
+-- Create enum type for import job status
+CREATE TYPE import_status AS ENUM ('queued', 'processing', 'completed', 'failed');
+
+CREATE TABLE IF NOT EXISTS import_jobs (
+    id BIGSERIAL PRIMARY KEY,
+    filename TEXT NOT NULL,
+    format VARCHAR(10) NOT NULL,
+    status import_status NOT NULL DEFAULT 'queued',
+    dry_run BOOLEAN NOT NULL DEFAULT FALSE,
+    total_rows INTEGER NOT NULL DEFAULT 0,
+    processed_rows INTEGER NOT NULL DEFAULT 0,
+    accepted_rows INTEGER NOT NULL DEFAULT 0,
+    rejected_rows INTEGER NOT NULL DEFAULT 0,
+    rejections JSONB NOT NULL DEFAULT '[]'::jsonb,
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMPTZ
+);
 
 INSERT INTO messages (phone_number, content)
 VALUES